@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/cli"
+)
+
+func main() {
+	ctx, stop := cli.InterruptContext(context.Background())
+	err := cli.Main(ctx, cadastur.NewService(), os.Args[1:])
+	stop()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erro:", err)
+		if errors.Is(err, cli.ErrInterrupted) {
+			os.Exit(130)
+		}
+		os.Exit(1)
+	}
+}
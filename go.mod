@@ -3,6 +3,6 @@ module cadastur-csv
 go 1.25.4
 
 require (
-	golang.org/x/net v0.47.0 // indirect
-	golang.org/x/text v0.31.0 // indirect
+	golang.org/x/net v0.47.0
+	golang.org/x/text v0.31.0
 )
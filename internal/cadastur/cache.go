@@ -0,0 +1,188 @@
+package cadastur
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached domain lists are used without asking the API.
+const DefaultCacheTTL = 7 * 24 * time.Hour
+
+// Source tells where a domain list came from.
+type Source string
+
+const (
+	SourceAPI      Source = "api"
+	SourceCache    Source = "cache"
+	SourceStale    Source = "cache expirado"
+	SourceSnapshot Source = "snapshot embutido"
+)
+
+// snapshot holds the domain lists shipped with the binary, used when neither
+// the API nor the cache are available. Refresh it from the API with
+// `go generate ./internal/cadastur` (see snapshot/gen).
+//
+//go:generate go run ./snapshot/gen
+//go:embed snapshot/*.json
+var snapshot embed.FS
+
+// Domains serves the UF and activity lists from an on-disk cache, calling the
+// API when the cache is missing, expired or a refresh is requested. When the
+// API fails, a stale cache entry or the embedded snapshot is used instead.
+type Domains struct {
+	service *Service
+	// Dir is the cache directory; empty disables the on-disk cache.
+	Dir string
+	// TTL is how long a cache entry is considered fresh.
+	TTL time.Duration
+	// Refresh skips fresh cache entries and always asks the API first.
+	Refresh bool
+
+	// The lists already loaded, reused by later calls (e.g. batch jobs).
+	mu         sync.Mutex
+	ufs        []UF
+	activities []Activity
+}
+
+// NewDomains creates a Domains using the default cache directory and TTL.
+// If the cache directory cannot be determined, only the API and the embedded
+// snapshot are used.
+func NewDomains(service *Service) *Domains {
+	dir, _ := DefaultCacheDir()
+	return &Domains{service: service, Dir: dir, TTL: DefaultCacheTTL}
+}
+
+// DefaultCacheDir returns the cache directory: $XDG_CACHE_HOME/cadastur-csv
+// (~/.cache/cadastur-csv) on Linux, and the platform equivalent elsewhere.
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "cadastur-csv"), nil
+}
+
+// UFs returns the UF list and where it came from. Once loaded, the list is
+// kept in memory for the lifetime of d.
+func (d *Domains) UFs(ctx context.Context) ([]UF, Source, error) {
+	return memo(ctx, d, &d.ufs, "ufs", d.service.FetchUFs)
+}
+
+// Activities returns the activity list and where it came from.
+func (d *Domains) Activities(ctx context.Context) ([]Activity, Source, error) {
+	return memo(ctx, d, &d.activities, "activities", d.service.FetchActivities)
+}
+
+// memo returns *loaded when set, otherwise loads the list and keeps it.
+// Fallback lists (stale cache, snapshot) are not kept, so the API is tried again.
+func memo[T any](ctx context.Context, d *Domains, loaded *[]T, name string, fetch func(context.Context) ([]T, error)) ([]T, Source, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if *loaded != nil {
+		return *loaded, SourceCache, nil
+	}
+	items, source, err := loadDomain(ctx, d, name, fetch)
+	if err == nil && (source == SourceAPI || source == SourceCache) {
+		*loaded = items
+	}
+	return items, source, err
+}
+
+// cacheEntry is the on-disk format of a cached domain list.
+type cacheEntry[T any] struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	Items     []T       `json:"items"`
+}
+
+func loadDomain[T any](ctx context.Context, d *Domains, name string, fetch func(context.Context) ([]T, error)) ([]T, Source, error) {
+	cached, cacheErr := readCache[T](d.Dir, name)
+	if cacheErr == nil && !d.Refresh && time.Since(cached.FetchedAt) < d.TTL {
+		slog.DebugContext(ctx, "domain list from cache", "list", name, "fetchedAt", cached.FetchedAt)
+		return cached.Items, SourceCache, nil
+	}
+
+	items, err := fetch(ctx)
+	if err == nil && len(items) == 0 {
+		err = errors.New("empty list")
+	}
+	if err == nil {
+		if d.Dir != "" {
+			// A cache that cannot be written only costs a request next time.
+			_ = writeCache(d.Dir, name, cacheEntry[T]{FetchedAt: time.Now(), Items: items})
+		}
+		return items, SourceAPI, nil
+	}
+	if ctx.Err() != nil {
+		return nil, "", err
+	}
+	slog.WarnContext(ctx, "domain list unavailable, using fallback", "list", name, "err", err)
+
+	if cacheErr == nil {
+		return cached.Items, SourceStale, nil
+	}
+	var snap []T
+	b, snapErr := snapshot.ReadFile("snapshot/" + name + ".json")
+	if snapErr == nil {
+		snapErr = json.Unmarshal(b, &snap)
+	}
+	if snapErr != nil {
+		return nil, "", fmt.Errorf("%w (no cache or snapshot: %v)", err, snapErr)
+	}
+	return snap, SourceSnapshot, nil
+}
+
+func cachePath(dir, name string) string {
+	return filepath.Join(dir, name+".json")
+}
+
+func readCache[T any](dir, name string) (cacheEntry[T], error) {
+	var entry cacheEntry[T]
+	if dir == "" {
+		return entry, os.ErrNotExist
+	}
+	b, err := os.ReadFile(cachePath(dir, name))
+	if err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return entry, err
+	}
+	if len(entry.Items) == 0 {
+		return entry, errors.New("empty cache entry")
+	}
+	return entry, nil
+}
+
+// writeCache stores entry atomically (temp file + rename), so a concurrent
+// run never reads a half-written file.
+func writeCache[T any](dir, name string, entry cacheEntry[T]) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath(dir, name))
+}
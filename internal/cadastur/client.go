@@ -4,17 +4,27 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
+
 	"golang.org/x/net/html/charset"
 )
 
 // Client handles HTTP requests to the Cadastur API.
 type Client struct {
 	httpClient *http.Client
+
+	// Requests start at least interval apart (0 = no limit); next is the
+	// earliest start of the next request.
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
 }
 
 // NewClient creates a new Client with a 30-second timeout.
+// Requests are logged through slog.Default().
 func NewClient() *Client {
 	return &Client{
 		httpClient: &http.Client{
@@ -25,66 +35,100 @@ func NewClient() *Client {
 
 // Get performs a GET request to the specified URL with context support.
 func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
+	return c.do(ctx, "GET", url, nil, "application/json", "")
+}
+
+// Post performs a POST request to the specified URL with JSON payload and context support.
+func (c *Client) Post(ctx context.Context, url string, payload []byte) ([]byte, error) {
+	return c.do(ctx, "POST", url, payload, "application/json, text/plain, */*", "application/json;charset=UTF-8")
+}
+
+// SetRateLimit spaces requests so at most perSecond start each second
+// (0 removes the limit).
+func (c *Client) SetRateLimit(perSecond float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interval = 0
+	if perSecond > 0 {
+		c.interval = time.Duration(float64(time.Second) / perSecond)
 	}
-	req.Header.Set("accept", "application/json")
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+// waitTurn blocks until the rate limit allows another request.
+func (c *Client) waitTurn(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	start := c.next
+	if start.Before(now) {
+		start = now
 	}
-	defer resp.Body.Close()
+	c.next = start.Add(c.interval)
+	c.mu.Unlock()
 
-	// Respect charset declared in Content-Type and convert to UTF-8 when needed.
-	reader, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
-	if err != nil {
-		// If unable to create reader for the declared charset, fall back to raw body.
-		b, err2 := io.ReadAll(resp.Body)
-		if err2 != nil {
-			return nil, err2
+	if d := time.Until(start); d > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
 		}
-		return b, nil
 	}
+	return nil
+}
 
-	body, err := io.ReadAll(reader)
+// do waits for the rate limit, sends the request and returns the body
+// converted to UTF-8. Every request is logged with its URL, status and latency.
+func (c *Client) do(ctx context.Context, method, url string, payload []byte, accept, contentType string) ([]byte, error) {
+	if err := c.waitTurn(ctx); err != nil {
+		return nil, err
+	}
+	body, status, err := c.once(ctx, method, url, payload, accept, contentType)
 	if err != nil {
+		slog.ErrorContext(ctx, "request failed", "method", method, "url", url, "status", status, "err", err)
 		return nil, err
 	}
-
 	return body, nil
 }
 
-// Post performs a POST request to the specified URL with JSON payload and context support.
-func (c *Client) Post(ctx context.Context, url string, payload []byte) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+// once performs the request. status is 0 when no response was received.
+// As before logging was added, error statuses are not errors: the body is
+// returned for the caller to decode.
+func (c *Client) once(ctx context.Context, method, url string, payload []byte, accept, contentType string) ([]byte, int, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	req.Header.Set("accept", accept)
+	if contentType != "" {
+		req.Header.Set("content-type", contentType)
 	}
-	req.Header.Set("accept", "application/json, text/plain, */*")
-	req.Header.Set("content-type", "application/json;charset=UTF-8")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	// Respect charset declared in Content-Type and convert to UTF-8 when needed.
 	reader, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
 	if err != nil {
-		b, err2 := io.ReadAll(resp.Body)
-		if err2 != nil {
-			return nil, err2
-		}
-		return b, nil
+		// If unable to create reader for the declared charset, fall back to raw body.
+		reader = resp.Body
 	}
-
 	body, err := io.ReadAll(reader)
+	latency := time.Since(start)
 	if err != nil {
-		return nil, err
+		return nil, resp.StatusCode, err
 	}
 
-	return body, nil
+	level := slog.LevelDebug
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	slog.Log(ctx, level, "request", "method", method, "url", url, "status", resp.StatusCode, "latency", latency, "bytes", len(body))
+	return body, resp.StatusCode, nil
 }
@@ -0,0 +1,229 @@
+package cadastur
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The Cadastur API is inconsistent about JSON types: the same field may come
+// as a number in one row and as a string (or boolean) in the next. The Flex*
+// types accept any scalar and never fail to unmarshal, so one odd field does
+// not make a whole page unreadable. Coercions are reported as Warnings.
+
+// FlexInt is an int that also accepts strings ("123"), booleans and null.
+// Values that are not numbers decode as 0; decimals are truncated.
+type FlexInt int64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *FlexInt) UnmarshalJSON(b []byte) error {
+	s := strings.TrimSpace(scalarText(b))
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*f = FlexInt(n)
+		return nil
+	}
+	if x, err := strconv.ParseFloat(s, 64); err == nil {
+		*f = FlexInt(x)
+		return nil
+	}
+	switch s {
+	case "true":
+		*f = 1
+	default:
+		*f = 0
+	}
+	return nil
+}
+
+// FlexString is a string that also accepts numbers, booleans and null (as "").
+type FlexString string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *FlexString) UnmarshalJSON(b []byte) error {
+	*f = FlexString(scalarText(b))
+	return nil
+}
+
+// FlexBool is a bool that also accepts strings ("true", "S", "sim", "1") and numbers.
+type FlexBool bool
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *FlexBool) UnmarshalJSON(b []byte) error {
+	switch strings.ToLower(strings.TrimSpace(scalarText(b))) {
+	case "true", "1", "s", "sim", "y", "yes":
+		*f = true
+	default:
+		*f = false
+	}
+	return nil
+}
+
+// scalarText returns the text of a JSON scalar: strings are unquoted, numbers
+// and booleans are kept verbatim, and null, objects and arrays are empty.
+func scalarText(b []byte) string {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || bytes.Equal(b, []byte("null")) || b[0] == '{' || b[0] == '[' {
+		return ""
+	}
+	if b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err == nil {
+			return s
+		}
+	}
+	return string(b)
+}
+
+// Warning records a provider field whose JSON type did not match the model
+// and was coerced, or a list entry that could not be decoded at all.
+type Warning struct {
+	PrestadorID int
+	Field       string
+	Detail      string
+}
+
+func (w Warning) String() string {
+	if w.Field == "" {
+		return fmt.Sprintf("prestador %d: %s", w.PrestadorID, w.Detail)
+	}
+	return fmt.Sprintf("prestador %d, campo %s: %s", w.PrestadorID, w.Field, w.Detail)
+}
+
+type warningKey struct{}
+
+// WithWarningHandler returns a context whose Service calls report coercion
+// warnings to fn. Without a handler, warnings are only logged.
+func WithWarningHandler(ctx context.Context, fn func(Warning)) context.Context {
+	return context.WithValue(ctx, warningKey{}, fn)
+}
+
+// warningHandler returns the context's handler, wrapped so every warning is
+// also logged at debug level.
+func warningHandler(ctx context.Context) func(Warning) {
+	fn, _ := ctx.Value(warningKey{}).(func(Warning))
+	return func(w Warning) {
+		slog.DebugContext(ctx, "field coerced", "prestador", w.PrestadorID, "field", w.Field, "detail", w.Detail)
+		if fn != nil {
+			fn(w)
+		}
+	}
+}
+
+// jsonKind is the JSON type a model field expects.
+type jsonKind string
+
+const (
+	kindNumber jsonKind = "number"
+	kindString jsonKind = "string"
+	kindBool   jsonKind = "boolean"
+	kindObject jsonKind = "object"
+	kindArray  jsonKind = "array"
+	kindNull   jsonKind = "null"
+)
+
+// prestadorKinds maps each JSON field of Prestador to the kind it expects.
+var prestadorKinds = fieldKinds(reflect.TypeOf(Prestador{}))
+
+func fieldKinds(t reflect.Type) map[string]jsonKind {
+	kinds := make(map[string]jsonKind)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		switch ft {
+		case reflect.TypeOf(FlexInt(0)):
+			kinds[name] = kindNumber
+		case reflect.TypeOf(FlexString("")):
+			kinds[name] = kindString
+		case reflect.TypeOf(FlexBool(false)):
+			kinds[name] = kindBool
+		}
+	}
+	return kinds
+}
+
+// coercions compares the JSON kinds of a raw provider object with the model
+// and returns one Warning per field that had to be coerced.
+func coercions(id int, raw json.RawMessage) []Warning {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	var warnings []Warning
+	for name, value := range fields {
+		want, ok := prestadorKinds[name]
+		if !ok {
+			continue
+		}
+		got := kindOf(value)
+		if got == kindNull {
+			continue
+		}
+		var detail string
+		if got != want {
+			detail = fmt.Sprintf("esperado %s, recebido %s %s", want, got, truncate(string(value), 40))
+		}
+		if want == kindNumber && (got == kindNumber || got == kindString) {
+			x, err := strconv.ParseFloat(strings.TrimSpace(scalarText(value)), 64)
+			switch {
+			case err != nil:
+				detail += " (não numérico, usado 0)"
+			case x != math.Trunc(x):
+				if detail == "" {
+					detail = fmt.Sprintf("esperado inteiro, recebido %s", truncate(string(value), 40))
+				}
+				detail += fmt.Sprintf(" (decimal truncado, usado %d)", int64(x))
+			}
+		}
+		if detail == "" {
+			continue
+		}
+		warnings = append(warnings, Warning{PrestadorID: id, Field: name, Detail: detail})
+	}
+	sort.Slice(warnings, func(i, j int) bool { return warnings[i].Field < warnings[j].Field })
+	return warnings
+}
+
+// kindOf returns the JSON kind of a raw value.
+func kindOf(v json.RawMessage) jsonKind {
+	v = bytes.TrimSpace(v)
+	if len(v) == 0 {
+		return kindNull
+	}
+	switch v[0] {
+	case 'n':
+		return kindNull
+	case '"':
+		return kindString
+	case 't', 'f':
+		return kindBool
+	case '{':
+		return kindObject
+	case '[':
+		return kindArray
+	}
+	return kindNumber
+}
+
+// truncate shortens s to at most n runes for warning messages.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
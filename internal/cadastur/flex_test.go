@@ -0,0 +1,182 @@
+package cadastur
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFlexInt(t *testing.T) {
+	tests := []struct {
+		in   string
+		want FlexInt
+	}{
+		{`123`, 123},
+		{`"123"`, 123},
+		{`" 42 "`, 42},
+		{`-7`, -7},
+		{`12.7`, 12},
+		{`"12.7"`, 12},
+		{`1e3`, 1000},
+		{`""`, 0},
+		{`"abc"`, 0},
+		{`null`, 0},
+		{`true`, 1},
+		{`false`, 0},
+		{`{"a":1}`, 0},
+		{`[1]`, 0},
+	}
+	for _, tt := range tests {
+		f := FlexInt(99)
+		if err := json.Unmarshal([]byte(tt.in), &f); err != nil {
+			t.Errorf("FlexInt(%s) error = %v", tt.in, err)
+			continue
+		}
+		if f != tt.want {
+			t.Errorf("FlexInt(%s) = %d, want %d", tt.in, f, tt.want)
+		}
+	}
+}
+
+func TestFlexString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`"Florianópolis"`, "Florianópolis"},
+		{`88010000`, "88010000"},
+		{`12.5`, "12.5"},
+		{`true`, "true"},
+		{`null`, ""},
+		{`""`, ""},
+		{`{"a":1}`, ""},
+	}
+	for _, tt := range tests {
+		var f FlexString
+		if err := json.Unmarshal([]byte(tt.in), &f); err != nil {
+			t.Errorf("FlexString(%s) error = %v", tt.in, err)
+			continue
+		}
+		if string(f) != tt.want {
+			t.Errorf("FlexString(%s) = %q, want %q", tt.in, f, tt.want)
+		}
+	}
+}
+
+func TestFlexBool(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{`true`, true},
+		{`"true"`, true},
+		{`"S"`, true},
+		{`"sim"`, true},
+		{`1`, true},
+		{`"1"`, true},
+		{`false`, false},
+		{`"N"`, false},
+		{`"não"`, false},
+		{`0`, false},
+		{`""`, false},
+		{`null`, false},
+	}
+	for _, tt := range tests {
+		var f FlexBool
+		if err := json.Unmarshal([]byte(tt.in), &f); err != nil {
+			t.Errorf("FlexBool(%s) error = %v", tt.in, err)
+			continue
+		}
+		if bool(f) != tt.want {
+			t.Errorf("FlexBool(%s) = %v, want %v", tt.in, f, tt.want)
+		}
+	}
+}
+
+func TestCoercions(t *testing.T) {
+	raw := json.RawMessage(`{
+		"id": 7,
+		"nuAtividadeTuristica": "31",
+		"nuUf": "SC",
+		"nuPessoa": 12.7,
+		"nuLocalidade": "8452.5",
+		"dtFimVigencia": null,
+		"nuCep": 88010000,
+		"nomePrestador": "GUIA",
+		"campoNovo": true
+	}`)
+	got := map[string]string{}
+	for _, w := range coercions(7, raw) {
+		if w.PrestadorID != 7 {
+			t.Errorf("warning %v has PrestadorID %d, want 7", w, w.PrestadorID)
+		}
+		got[w.Field] = w.Detail
+	}
+	want := map[string]string{
+		"nuAtividadeTuristica": "esperado number, recebido string",
+		"nuUf":                 "não numérico, usado 0",
+		"nuPessoa":             "decimal truncado, usado 12",
+		"nuLocalidade":         "decimal truncado, usado 8452",
+		"nuCep":                "esperado string, recebido number",
+	}
+	if len(got) != len(want) {
+		t.Errorf("coercions = %v, want fields %v", got, want)
+	}
+	for field, detail := range want {
+		if !strings.Contains(got[field], detail) {
+			t.Errorf("coercions[%s] = %q, want it to contain %q", field, got[field], detail)
+		}
+	}
+	if strings.Contains(got["nuAtividadeTuristica"], "não numérico") {
+		t.Errorf("coercions[nuAtividadeTuristica] = %q, want no non-numeric note", got["nuAtividadeTuristica"])
+	}
+}
+
+func TestDecodePage(t *testing.T) {
+	body := []byte(`{
+		"totalResults": "3",
+		"list": [
+			{"id": 1, "nomePrestador": "GUIA UM", "nuUf": "42"},
+			{"id": 2, "nomePrestador": {"broken": true}, "localidadeNuUf": [1]},
+			"not an object",
+			{"id": "3", "nomePrestador": "GUIA TRÊS", "nuPessoa": 4.5}
+		]
+	}`)
+	var warnings []Warning
+	list, received, total, err := decodePage(body, func(w Warning) { warnings = append(warnings, w) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != 4 || total != 3 {
+		t.Errorf("received, total = %d, %d, want 4, 3", received, total)
+	}
+	var ids []FlexInt
+	for _, p := range list {
+		ids = append(ids, p.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Fatalf("decoded ids = %v, want [1 2 3]", ids)
+	}
+	if list[0].NuUf != 42 || list[1].NomePrestador != "" || list[2].NuPessoa != 4 {
+		t.Errorf("decoded = %+v", list)
+	}
+
+	var skipped, coerced int
+	for _, w := range warnings {
+		if w.Field == "" {
+			skipped++
+			if !strings.HasPrefix(w.Detail, "registro ignorado") {
+				t.Errorf("skip warning = %q", w.Detail)
+			}
+		} else {
+			coerced++
+		}
+	}
+	// nuUf and nomePrestador/localidadeNuUf of entry 2, nuPessoa and id of entry 3.
+	if skipped != 1 || coerced != 5 {
+		t.Errorf("got %d skipped and %d coerced warnings, want 1 and 5: %v", skipped, coerced, warnings)
+	}
+
+	if _, _, _, err := decodePage([]byte(`<html>`), func(Warning) {}); err == nil {
+		t.Error("decodePage accepted a non-JSON body")
+	}
+}
@@ -2,17 +2,17 @@ package cadastur
 
 // UF represents a Federative Unit (state) returned by Cadastur `tipoUfs`.
 type UF struct {
-	ID   int    `json:"id"`
-	NoUf string `json:"noUf"`
-	SgUf string `json:"sgUf"`
+	ID   FlexInt    `json:"id"`
+	NoUf FlexString `json:"noUf"`
+	SgUf FlexString `json:"sgUf"`
 }
 
 // Activity represents a tourism activity entry from `atividadesTuristica`.
 type Activity struct {
-	NuAtividadeTuristica   int    `json:"nuAtividadeTuristica"`
-	NoAtividadeTuristica   string `json:"noAtividadeTuristica"`
-	FlAtividadeObrigatoria bool   `json:"flAtividadeObrigatoria"`
-	FlAtivo                bool   `json:"flAtivo"`
+	NuAtividadeTuristica   FlexInt    `json:"nuAtividadeTuristica"`
+	NoAtividadeTuristica   FlexString `json:"noAtividadeTuristica"`
+	FlAtividadeObrigatoria FlexBool   `json:"flAtividadeObrigatoria"`
+	FlAtivo                FlexBool   `json:"flAtivo"`
 }
 
 // Filtros matches the request body's "filtros" expected by `obterDadosPrestadores`.
@@ -38,47 +38,49 @@ type RequestBody struct {
 
 // Response models the paginated response from `obterDadosPrestadores`.
 type Response struct {
-	CurrentPage    int         `json:"currentPage"`
-	PageSize       int         `json:"pageSize"`
-	TotalResults   int         `json:"totalResults"`
-	SortFields     string      `json:"sortFields"`
-	SortDirections string      `json:"sortDirections"`
+	CurrentPage    FlexInt     `json:"currentPage"`
+	PageSize       FlexInt     `json:"pageSize"`
+	TotalResults   FlexInt     `json:"totalResults"`
+	SortFields     FlexString  `json:"sortFields"`
+	SortDirections FlexString  `json:"sortDirections"`
 	Filtros        Filtros     `json:"filtros"`
 	List           []Prestador `json:"list"`
-	Start          int         `json:"start"`
+	Start          FlexInt     `json:"start"`
 }
 
 // Prestador represents one provider row returned in `Response.List`.
+// Every field uses a Flex* type because the API mixes numbers, strings and
+// booleans for the same field; see flex.go.
 type Prestador struct {
-	ID                   int     `json:"id"`
-	TipoPessoa           string  `json:"tipoPessoa"`
-	NumeroCadastro       string  `json:"numeroCadastro"`
-	DtInicioVigencia     int64   `json:"dtInicioVigencia"`
-	DtFimVigencia        int64   `json:"dtFimVigencia"`
-	NoWebSite            *string `json:"noWebSite"`
-	NuTelefone           string  `json:"nuTelefone"`
-	NoLogradouro         string  `json:"noLogradouro"`
-	Complemento          string  `json:"complemento"`
-	NuCep                string  `json:"nuCep"`
-	Sguf                 string  `json:"sguf"`
-	NoBairro             string  `json:"noBairro"`
-	NomePrestador        string  `json:"nomePrestador"`
-	RegistroRf           string  `json:"registroRf"`
-	NuAtividadeTuristica int     `json:"nuAtividadeTuristica"`
-	Atividade            string  `json:"atividade"`
-	NuSituacaoCadastral  int     `json:"nuSituacaoCadastral"`
-	Situacao             string  `json:"situacao"`
-	NuUf                 int     `json:"nuUf"`
-	LocalidadeNuUf       *int    `json:"localidadeNuUf"`
-	Localidade           string  `json:"localidade"`
-	NoLocalidade         string  `json:"noLocalidade"`
-	NuLocalidade         int     `json:"nuLocalidade"`
-	NuPessoa             int     `json:"nuPessoa"`
-	// natJuridEspecial can come as string or boolean from the API; accept any to avoid unmarshal errors.
-	NatJuridEspecial     any     `json:"natJuridEspecial"`
-	Municipio            string  `json:"municipio"`
-	NuMunicipio          int     `json:"nuMunicipio"`
-	FlPossuiVeiculo      bool    `json:"flPossuiVeiculo"`
-	NuSitCadTramite      int     `json:"nuSitCadTramite"`
-	AtividadeRedeSociais *string `json:"atividadeRedeSociais"`
+	ID                   FlexInt    `json:"id"`
+	TipoPessoa           FlexString `json:"tipoPessoa"`
+	NumeroCadastro       FlexString `json:"numeroCadastro"`
+	DtInicioVigencia     FlexInt    `json:"dtInicioVigencia"`
+	DtFimVigencia        FlexInt    `json:"dtFimVigencia"`
+	NoWebSite            FlexString `json:"noWebSite"`
+	NuTelefone           FlexString `json:"nuTelefone"`
+	NoLogradouro         FlexString `json:"noLogradouro"`
+	Complemento          FlexString `json:"complemento"`
+	NuCep                FlexString `json:"nuCep"`
+	Sguf                 FlexString `json:"sguf"`
+	NoBairro             FlexString `json:"noBairro"`
+	NomePrestador        FlexString `json:"nomePrestador"`
+	RegistroRf           FlexString `json:"registroRf"`
+	NuAtividadeTuristica FlexInt    `json:"nuAtividadeTuristica"`
+	Atividade            FlexString `json:"atividade"`
+	NuSituacaoCadastral  FlexInt    `json:"nuSituacaoCadastral"`
+	Situacao             FlexString `json:"situacao"`
+	NuUf                 FlexInt    `json:"nuUf"`
+	LocalidadeNuUf       *FlexInt   `json:"localidadeNuUf"`
+	Localidade           FlexString `json:"localidade"`
+	NoLocalidade         FlexString `json:"noLocalidade"`
+	NuLocalidade         FlexInt    `json:"nuLocalidade"`
+	NuPessoa             FlexInt    `json:"nuPessoa"`
+	// natJuridEspecial comes as string or boolean from the API; kept as text.
+	NatJuridEspecial     FlexString `json:"natJuridEspecial"`
+	Municipio            FlexString `json:"municipio"`
+	NuMunicipio          FlexInt    `json:"nuMunicipio"`
+	FlPossuiVeiculo      FlexBool   `json:"flPossuiVeiculo"`
+	NuSitCadTramite      FlexInt    `json:"nuSitCadTramite"`
+	AtividadeRedeSociais FlexString `json:"atividadeRedeSociais"`
 }
@@ -3,6 +3,8 @@ package cadastur
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"time"
 )
 
 // Service provides methods to interact with the Cadastur API.
@@ -17,6 +19,16 @@ func NewService() *Service {
 	}
 }
 
+// SetTimeout changes the time limit of each HTTP request (30s by default).
+func (s *Service) SetTimeout(d time.Duration) {
+	s.client.httpClient.Timeout = d
+}
+
+// SetRateLimit caps the requests started per second (0 = unlimited).
+func (s *Service) SetRateLimit(perSecond float64) {
+	s.client.SetRateLimit(perSecond)
+}
+
 // FetchUFs retrieves the list of UFs (states) from Cadastur.
 // It returns a slice of UF or an error on network/parse failures.
 func (s *Service) FetchUFs(ctx context.Context) ([]UF, error) {
@@ -50,7 +62,9 @@ func (s *Service) FetchActivities(ctx context.Context) ([]Activity, error) {
 
 // FetchPrestadoresPaged fetches providers data with pagination.
 // It calls onPage callback for each page of results.
-// Continues fetching while the page size is full (len(List) >= pageSize).
+// Continues fetching while the page size is full (len(List) >= pageSize);
+// the entries skipped by decodePage still count, so a bad record does not
+// end the export early.
 func (s *Service) FetchPrestadoresPaged(ctx context.Context, filters Filtros, pageSize int, onPage func([]Prestador, int, int) error) error {
 	for currentPage := 1; ; currentPage++ {
 		// Create and POST the request body for the current page.
@@ -67,23 +81,26 @@ func (s *Service) FetchPrestadoresPaged(ctx context.Context, filters Filtros, pa
 			return err
 		}
 
+		start := time.Now()
 		respBody, err := s.client.Post(ctx, EndpointPrestadores, payload)
 		if err != nil {
 			return err
 		}
 
-		var out Response
-		if err := json.Unmarshal(respBody, &out); err != nil {
+		list, received, total, err := decodePage(respBody, warningHandler(ctx))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to decode page", "page", currentPage, "err", err)
 			return err
 		}
+		slog.InfoContext(ctx, "page fetched", "page", currentPage, "rows", len(list), "skipped", received-len(list), "total", total, "latency", time.Since(start))
 
 		// Call the callback with the current page's providers, page number, and total results
-		if err := onPage(out.List, currentPage, out.TotalResults); err != nil {
+		if err := onPage(list, currentPage, total); err != nil {
 			return err
 		}
 
-		// Stop if we got less than pageSize results (last page)
-		if len(out.List) < pageSize {
+		// Stop if the API sent less than pageSize results (last page)
+		if received < pageSize {
 			break
 		}
 	}
@@ -91,6 +108,34 @@ func (s *Service) FetchPrestadoresPaged(ctx context.Context, filters Filtros, pa
 	return nil
 }
 
+// decodePage decodes one `obterDadosPrestadores` response. Providers are
+// decoded one by one, so an entry that cannot be read is skipped (and
+// reported) instead of failing the page; type coercions are reported too.
+// received is the number of entries in the response, skipped ones included.
+func decodePage(body []byte, warn func(Warning)) (list []Prestador, received, total int, err error) {
+	var page struct {
+		TotalResults FlexInt           `json:"totalResults"`
+		List         []json.RawMessage `json:"list"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, 0, 0, err
+	}
+
+	list = make([]Prestador, 0, len(page.List))
+	for _, raw := range page.List {
+		var p Prestador
+		if err := json.Unmarshal(raw, &p); err != nil {
+			warn(Warning{Detail: "registro ignorado: " + err.Error()})
+			continue
+		}
+		for _, w := range coercions(int(p.ID), raw) {
+			warn(w)
+		}
+		list = append(list, p)
+	}
+	return list, len(page.List), int(page.TotalResults), nil
+}
+
 // BuildFilters creates a Filtros struct with the provided parameters.
 // Note: Localidade is hardcoded to 8452 as per original implementation.
 func BuildFilters(selectedUF int, selectedActName string, localidadesUfs string) Filtros {
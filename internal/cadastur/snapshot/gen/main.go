@@ -0,0 +1,63 @@
+// Command gen refreshes the domain lists embedded in package cadastur from
+// the Cadastur API. Run it online from the repository root with
+//
+//	go generate ./internal/cadastur
+//
+// and commit the updated snapshot/*.json files.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	service := cadastur.NewService()
+
+	ufs, err := service.FetchUFs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch UFs: %w", err)
+	}
+	// Inactive activities are kept: activities --all lists them offline too.
+	acts, err := service.FetchActivities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch activities: %w", err)
+	}
+	if len(ufs) < 27 || len(acts) < 2 {
+		return fmt.Errorf("suspiciously short lists (%d UFs, %d activities), snapshot not updated", len(ufs), len(acts))
+	}
+
+	if err := write("ufs.json", ufs); err != nil {
+		return err
+	}
+	if err := write("activities.json", acts); err != nil {
+		return err
+	}
+	fmt.Printf("Snapshot atualizado: %d UFs, %d atividades\n", len(ufs), len(acts))
+	return nil
+}
+
+// write stores v as indented JSON in the snapshot directory (go generate
+// runs in the package directory).
+func write(name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join("snapshot", name), append(b, '\n'), 0o644)
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/config"
+)
+
+// Batch job statuses in the run report.
+const (
+	JobOK      = "ok"
+	JobFailed  = "failed"
+	JobSkipped = "skipped"
+)
+
+// JobReport is the outcome of one batch job.
+type JobReport struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt,omitzero"`
+	DurationMs int64     `json:"durationMs"`
+	Result
+}
+
+// BatchReport is the consolidated report written after a batch run.
+type BatchReport struct {
+	Manifest   string      `json:"manifest"`
+	StartedAt  time.Time   `json:"startedAt"`
+	DurationMs int64       `json:"durationMs"`
+	OK         int         `json:"ok"`
+	Failed     int         `json:"failed"`
+	Skipped    int         `json:"skipped"`
+	Jobs       []JobReport `json:"jobs"`
+}
+
+// RunBatch runs every job of a manifest with one client, rate limiter and
+// domain cache, and writes a JSON report with per-job status, duration and
+// row counts. A failed job stops the batch unless --continue-on-error (or
+// continue-on-error in the [batch] table) is set; the remaining jobs are
+// then reported as skipped. Webhooks of the manifest are told of every
+// finished or failed job.
+// Usage: batch [--continue-on-error] [--report f] [--rate 2] <manifest.toml>
+func RunBatch(ctx context.Context, service *cadastur.Service, args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	keepGoing := fs.Bool("continue-on-error", false, "continua com os próximos jobs quando um falha")
+	reportPath := fs.String("report", "batch-report.json", "arquivo do relatório consolidado (JSON)")
+	rate := fs.Float64("rate", 2, "máximo de requisições por segundo, somando todos os jobs (0 = sem limite)")
+	refresh := fs.Bool("refresh", false, "atualiza as listas de UFs e atividades mesmo com cache válido")
+	cacheTTL := fs.Duration("cache-ttl", cadastur.DefaultCacheTTL, "validade do cache de UFs e atividades")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: batch [--continue-on-error] [--report file] [--rate 2] <manifest.toml>")
+	}
+	manifest, err := config.LoadManifest(fs.Arg(0), "batch")
+	if err != nil {
+		return err
+	}
+	if err := setRunnerFlags(fs, manifest.Runner, "[batch]"); err != nil {
+		return err
+	}
+	notifier, err := newNotifier(manifest, "batch")
+	if err != nil {
+		return err
+	}
+
+	service.SetRateLimit(*rate)
+	domains := cadastur.NewDomains(service)
+	domains.TTL, domains.Refresh = *cacheTTL, *refresh
+
+	report := BatchReport{Manifest: manifest.Path, StartedAt: time.Now()}
+	stopped := false
+	for _, job := range manifest.Jobs {
+		jr := JobReport{Name: job.Name, Status: JobSkipped}
+		if !stopped {
+			section(fmt.Sprintf("Job %s", job.Name))
+			jr = runJob(ctx, service, domains, job, nil)
+			if jr.Status == JobFailed {
+				fmt.Fprintf(os.Stderr, "Job %s falhou: %s\n", job.Name, jr.Error)
+				stopped = ctx.Err() != nil || !*keepGoing
+			}
+			notifier.notify(ctx, jr, nil)
+		}
+		switch jr.Status {
+		case JobOK:
+			report.OK++
+		case JobFailed:
+			report.Failed++
+		default:
+			report.Skipped++
+		}
+		report.Jobs = append(report.Jobs, jr)
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+
+	if err := writeBatchReport(*reportPath, report); err != nil {
+		return fmt.Errorf("failed to write batch report: %w", err)
+	}
+	section("Resumo do batch")
+	for _, jr := range report.Jobs {
+		fmt.Printf("%-24s %-8s %8d linhas %8s  %s\n", jr.Name, jr.Status, jr.Rows, (time.Duration(jr.DurationMs) * time.Millisecond).String(), jr.Output)
+	}
+	fmt.Printf("OK: %d | Falhas: %d | Ignorados: %d | Relatório: %s\n", report.OK, report.Failed, report.Skipped, *reportPath)
+
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d batch jobs failed", report.Failed, len(report.Jobs))
+	}
+	return nil
+}
+
+// setRunnerFlags applies the runner table of a manifest to the flags of the
+// same name, unless they were given on the command line.
+func setRunnerFlags(fs *flag.FlagSet, values map[string]string, origin string) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for name, v := range values {
+		if explicit[name] {
+			continue
+		}
+		if err := setFlags(fs, map[string]string{name: v}, origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jobOptions parses a job's settings as fetch flags. Jobs cannot prompt, so
+// uf and activity are required.
+func jobOptions(job config.Job) (Options, error) {
+	names := make([]string, 0, len(job.Settings))
+	for name := range job.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make([]string, 0, len(names))
+	for _, name := range names {
+		args = append(args, "--"+name+"="+job.Settings[name])
+	}
+
+	opts, err := ParseOptions(args)
+	if err != nil {
+		return Options{}, err
+	}
+	if opts.UF == 0 || opts.Activity == 0 {
+		return Options{}, errors.New("uf and activity are required (jobs cannot prompt)")
+	}
+	return opts, nil
+}
+
+// runJob runs the export of a job. adjust, when not nil, can change the
+// parsed options first (e.g. to place the output in a snapshot directory).
+func runJob(ctx context.Context, service *cadastur.Service, domains *cadastur.Domains, job config.Job, adjust func(*Options) error) JobReport {
+	jr := JobReport{Name: job.Name, StartedAt: time.Now()}
+	err := func() error {
+		opts, err := jobOptions(job)
+		if err != nil {
+			return err
+		}
+		if adjust != nil {
+			if err := adjust(&opts); err != nil {
+				return err
+			}
+		}
+		return runExport(ctx, service, domains, opts, &jr.Result)
+	}()
+	jr.DurationMs = time.Since(jr.StartedAt).Milliseconds()
+	jr.Status = JobOK
+	if err != nil {
+		jr.Status, jr.Error = JobFailed, err.Error()
+	}
+	return jr
+}
+
+func writeBatchReport(path string, report BatchReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+// Main dispatches args to a subcommand. Without a known command name the
+// interactive fetch runs, so "cadastur-csv --split-by municipio" keeps working.
+// --log-level and --log-format are accepted by every command and configure
+// the default slog logger (stderr).
+func Main(ctx context.Context, service *cadastur.Service, args []string) error {
+	logOpts, args, err := extractLogFlags(args)
+	if err != nil {
+		return err
+	}
+	logger, err := logOpts.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	err = dispatch(ctx, service, args)
+	// The caller prints the error for people; JSON logs also get it, so log
+	// aggregators see why a scheduled run failed.
+	if err != nil && !errors.Is(err, flag.ErrHelp) && logOpts.Format == "json" {
+		slog.ErrorContext(ctx, "command failed", "args", args, "err", err)
+	}
+	return err
+}
+
+func dispatch(ctx context.Context, service *cadastur.Service, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "fetch":
+			return runFetch(ctx, service, args[1:])
+		case "convert":
+			return RunConvert(args[1:])
+		case "diff":
+			return RunDiff(args[1:])
+		case "expiring":
+			return RunExpiring(args[1:])
+		case "ufs":
+			return RunUFs(ctx, service, args[1:])
+		case "activities":
+			return RunActivities(ctx, service, args[1:])
+		case "batch":
+			return RunBatch(ctx, service, args[1:])
+		case "serve":
+			return RunServe(ctx, service, args[1:])
+		case "daemon":
+			return RunDaemon(ctx, service, args[1:])
+		case "webhook-test":
+			return RunWebhookTest(ctx, args[1:])
+		}
+	}
+	return runFetch(ctx, service, args)
+}
+
+// runFetch parses the fetch flags and runs the interactive export.
+func runFetch(ctx context.Context, service *cadastur.Service, args []string) error {
+	opts, err := ParseOptions(args)
+	if err != nil {
+		return err
+	}
+	return Run(ctx, service, opts)
+}
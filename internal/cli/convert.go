@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"cadastur-csv/internal/csvx"
+)
+
+// RunConvert re-encodes an export between CSV and NDJSON, with or without gzip.
+// Usage: convert [--format csv|ndjson] [--compress] <entrada> <saida>
+func RunConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	format := fs.String("format", "", "formato de saída: csv ou ndjson (padrão: pela extensão do arquivo)")
+	compress := fs.Bool("compress", false, "compacta a saída com gzip (implícito quando o arquivo termina em .gz)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: convert [--format csv|ndjson] [--compress] <input> <output>")
+	}
+	inPath, outPath := fs.Arg(0), fs.Arg(1)
+
+	opts := csvx.Options{Compress: *compress}
+	if *format != "" {
+		f, err := csvx.ParseFormat(*format)
+		if err != nil {
+			return err
+		}
+		opts.Format = f
+	}
+	if opts.Compress && !csvx.IsCompressedPath(outPath) {
+		outPath += ".gz"
+	}
+
+	r, err := csvx.OpenReader(inPath)
+	if err != nil {
+		return fmt.Errorf("failed to open input: %w", err)
+	}
+	defer r.Close()
+
+	header := r.Header()
+	opts.Columns = csvx.NamedColumns(header)
+	w, err := csvx.Create(outPath, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+	defer w.Close()
+
+	if err := w.WriteHeader(); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	rows := 0
+	values := make([]string, len(header))
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", inPath, err)
+		}
+		for i, name := range header {
+			values[i] = row[name]
+		}
+		if err := w.WriteRecord(values); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+		rows++
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close output: %w", err)
+	}
+	fmt.Printf("Convertidas %d linhas: %s (%s) → %s\n", rows, inPath, r.Format(), outPath)
+	return nil
+}
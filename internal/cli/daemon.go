@@ -0,0 +1,441 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/config"
+	"cadastur-csv/internal/cron"
+	"cadastur-csv/internal/csvx"
+	"cadastur-csv/internal/normalize"
+	"cadastur-csv/internal/webhook"
+)
+
+// snapshotDateLayout names the dated snapshot directories.
+const snapshotDateLayout = "2006-01-02"
+
+// DaemonJobStatus is the state of one daemon job in the status file.
+type DaemonJobStatus struct {
+	Name        string     `json:"name"`
+	Schedule    string     `json:"schedule"`
+	NextRun     time.Time  `json:"nextRun,omitzero"`
+	Running     bool       `json:"running"`
+	LastRun     *JobReport `json:"lastRun,omitempty"`
+	LastSuccess *JobReport `json:"lastSuccess,omitempty"`
+	LastFailure *JobReport `json:"lastFailure,omitempty"`
+}
+
+// DaemonStatus is written to the status file after every run and served by
+// --status-addr.
+type DaemonStatus struct {
+	Manifest  string            `json:"manifest"`
+	Snapshots string            `json:"snapshots"`
+	StartedAt time.Time         `json:"startedAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Jobs      []DaemonJobStatus `json:"jobs"`
+}
+
+// daemonJob is a manifest job with its schedule.
+type daemonJob struct {
+	job      config.Job
+	schedule *cron.Schedule
+	// next is written by loop under daemon.mu, since the status server reads it.
+	next time.Time
+}
+
+// daemon runs the jobs and keeps the status.
+type daemon struct {
+	service    *cadastur.Service
+	domains    *cadastur.Domains
+	root       string
+	retention  int
+	statusPath string
+	jobs       []*daemonJob
+	notifier   *notifier
+	diffKey    string
+
+	mu     sync.Mutex
+	status DaemonStatus
+}
+
+// RunDaemon runs the jobs of a manifest on their cron schedules, writing
+// each run to a dated snapshot directory (<snapshots>/2026-10-16/<job>/)
+// and removing the snapshots older than the retention. The status file
+// (and, with --status-addr, GET /status) shows the next, last, last
+// successful and last failed run of every job. Runs never overlap: a job
+// due while another runs starts when it finishes. Schedules are evaluated
+// in Brasília time, like the snapshot dates. Webhooks of the manifest are
+// told of every run, and of the providers added or removed since the
+// previous successful run of the job.
+// Usage: daemon [--snapshots dir] [--retention-days 30] [--status file] [--status-addr host:port] <manifest.toml>
+func RunDaemon(ctx context.Context, service *cadastur.Service, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	root := fs.String("snapshots", "snapshots", "diretório das execuções, uma pasta por data")
+	retention := fs.Int("retention-days", 30, "apaga as pastas de data mais antigas que este número de dias (0 = mantém todas)")
+	statusPath := fs.String("status", "", "arquivo de status (padrão: status.json no diretório de snapshots)")
+	statusAddr := fs.String("status-addr", "", "endereço para servir o status em GET /status (ex.: localhost:8081; vazio = desligado)")
+	rate := fs.Float64("rate", 2, "máximo de requisições por segundo, somando todos os jobs (0 = sem limite)")
+	refresh := fs.Bool("refresh", false, "atualiza as listas de UFs e atividades mesmo com cache válido")
+	cacheTTL := fs.Duration("cache-ttl", cadastur.DefaultCacheTTL, "validade do cache de UFs e atividades")
+	diffKey := fs.String("diff-key", csvx.DefaultKey, "coluna que identifica o prestador ao comparar com a execução anterior: id ou numeroCadastro")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: daemon [--snapshots dir] [--retention-days 30] [--status file] [--status-addr host:port] <manifest.toml>")
+	}
+	manifest, err := config.LoadManifest(fs.Arg(0), "daemon")
+	if err != nil {
+		return err
+	}
+	if err := setRunnerFlags(fs, manifest.Runner, "[daemon]"); err != nil {
+		return err
+	}
+	if *retention < 0 {
+		return fmt.Errorf("--retention-days must not be negative")
+	}
+	notifier, err := newNotifier(manifest, "daemon")
+	if err != nil {
+		return err
+	}
+	if *statusPath == "" {
+		*statusPath = filepath.Join(*root, "status.json")
+	}
+
+	d := &daemon{
+		root:       *root,
+		retention:  *retention,
+		statusPath: *statusPath,
+		notifier:   notifier,
+		diffKey:    *diffKey,
+		status:     DaemonStatus{Manifest: manifest.Path, Snapshots: *root, StartedAt: time.Now()},
+	}
+	// Check every job now, so a typo does not wait for its first run.
+	now := time.Now().In(normalize.CadasturLocation)
+	for _, job := range manifest.Jobs {
+		dj, err := newDaemonJob(job, now)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		d.jobs = append(d.jobs, dj)
+	}
+	if err := os.MkdirAll(d.root, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+	d.loadStatus()
+
+	service.SetRateLimit(*rate)
+	d.service = service
+	d.domains = cadastur.NewDomains(service)
+	d.domains.TTL, d.domains.Refresh = *cacheTTL, *refresh
+
+	if *statusAddr != "" {
+		stop, err := d.serveStatus(ctx, *statusAddr)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	return d.loop(ctx)
+}
+
+// newDaemonJob parses the schedule setting of a job and checks its fetch settings.
+func newDaemonJob(job config.Job, now time.Time) (*daemonJob, error) {
+	expr, ok := job.Settings["schedule"]
+	if !ok {
+		return nil, errors.New(`missing schedule (e.g. schedule = "0 6 * * *")`)
+	}
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.Next(now).IsZero() {
+		return nil, fmt.Errorf("schedule %q never runs", expr)
+	}
+	settings := maps.Clone(job.Settings)
+	delete(settings, "schedule")
+	job.Settings = settings
+	if _, err := jobOptions(job); err != nil {
+		return nil, err
+	}
+	return &daemonJob{job: job, schedule: schedule}, nil
+}
+
+// loop waits for the next due job, runs every due job in manifest order,
+// prunes old snapshots and saves the status, until ctx is canceled.
+func (d *daemon) loop(ctx context.Context) error {
+	now := time.Now().In(normalize.CadasturLocation)
+	for _, j := range d.jobs {
+		d.schedule(j, now)
+	}
+	d.saveStatus()
+
+	for {
+		next := d.jobs[0]
+		for _, j := range d.jobs[1:] {
+			if j.next.Before(next.next) {
+				next = j
+			}
+		}
+		fmt.Printf("Próxima execução: %s em %s\n", next.job.Name, next.next.Format("02/01/2006 15:04"))
+
+		timer := time.NewTimer(time.Until(next.next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			fmt.Println("Daemon encerrado.")
+			return nil
+		case <-timer.C:
+		}
+
+		for _, j := range d.jobs {
+			if j.next.After(time.Now()) {
+				continue
+			}
+			d.run(ctx, j)
+			if ctx.Err() != nil {
+				d.saveStatus()
+				fmt.Println("Daemon encerrado.")
+				return nil
+			}
+			d.schedule(j, time.Now().In(normalize.CadasturLocation))
+		}
+		d.prune()
+		d.saveStatus()
+	}
+}
+
+// schedule sets the next run of j after now.
+func (d *daemon) schedule(j *daemonJob, now time.Time) {
+	next := j.schedule.Next(now)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	j.next = next
+}
+
+// run exports one job into today's snapshot directory, records the outcome
+// and sends the webhooks.
+func (d *daemon) run(ctx context.Context, j *daemonJob) {
+	date := time.Now().In(normalize.CadasturLocation).Format(snapshotDateLayout)
+	dir := filepath.Join(d.root, date, j.job.Name)
+	// A second run on the same day overwrites the previous export, so it
+	// is read before the run.
+	var prev *previousRun
+	if d.notifier.wantsChanges(j.job.Name) {
+		prev = d.previousRun(j)
+	}
+	d.update(j, func(s *DaemonJobStatus) { s.Running = true })
+	d.saveStatus()
+
+	section(fmt.Sprintf("Job %s (%s)", j.job.Name, dir))
+	jr := runJob(ctx, d.service, d.domains, j.job, func(o *Options) error {
+		return snapshotOptions(o, dir)
+	})
+	if jr.Status == JobOK {
+		slog.InfoContext(ctx, "daemon job finished", "job", j.job.Name, "output", jr.Output, "rows", jr.Rows, "duration_ms", jr.DurationMs)
+	} else {
+		slog.ErrorContext(ctx, "daemon job failed", "job", j.job.Name, "err", jr.Error, "duration_ms", jr.DurationMs)
+		fmt.Fprintf(os.Stderr, "Job %s falhou: %s\n", j.job.Name, jr.Error)
+	}
+	d.update(j, func(s *DaemonJobStatus) {
+		s.Running = false
+		s.LastRun = &jr
+		if jr.Status == JobOK {
+			s.LastSuccess = &jr
+		} else {
+			s.LastFailure = &jr
+		}
+	})
+
+	var changes *webhook.Changes
+	if prev != nil && jr.Status == JobOK {
+		var err error
+		if changes, err = compareRuns(prev.path, prev.header, prev.rows, jr.Output, d.diffKey); err != nil {
+			slog.WarnContext(ctx, "failed to compare with previous run", "job", j.job.Name, "previous", prev.path, "err", err)
+		}
+	}
+	d.notifier.notify(ctx, jr, changes)
+}
+
+// previousRun is the export of the last successful run of a job.
+type previousRun struct {
+	path   string
+	header []string
+	rows   []map[string]string
+}
+
+// previousRun reads the export of the last successful run of j. It returns
+// nil when there is none, the job is split (its output is an index) or the
+// file is gone, e.g. removed by the retention.
+func (d *daemon) previousRun(j *daemonJob) *previousRun {
+	d.mu.Lock()
+	var last *JobReport
+	for _, s := range d.status.Jobs {
+		if s.Name == j.job.Name {
+			last = s.LastSuccess
+		}
+	}
+	d.mu.Unlock()
+	if last == nil || last.Output == "" {
+		return nil
+	}
+	if opts, err := jobOptions(j.job); err != nil || opts.SplitBy != "" {
+		return nil
+	}
+	header, rows, err := readExport(last.Output)
+	if err != nil {
+		slog.Warn("previous run not available for comparison", "job", j.job.Name, "err", err)
+		return nil
+	}
+	return &previousRun{path: last.Output, header: header, rows: rows}
+}
+
+// snapshotOptions places every file of an export (the output, split
+// partitions and index, stats and checkpoint) under dir. Relative paths in
+// the job settings are kept, relative to dir.
+func snapshotOptions(o *Options, dir string) error {
+	if o.Output != "" {
+		o.Output = filepath.Join(dir, o.Output)
+	} else {
+		o.OutputTemplate = filepath.Join(dir, o.OutputTemplate)
+	}
+	if o.SplitBy != "" {
+		if o.SplitTemplate == "" {
+			o.SplitTemplate = csvx.DefaultSplitTemplate(o.SplitBy, csvx.Options{Format: o.Format, Compress: o.Compress})
+		}
+		o.SplitTemplate = filepath.Join(dir, o.SplitTemplate)
+		o.SplitIndex = filepath.Join(dir, o.SplitIndex)
+		return os.MkdirAll(filepath.Dir(o.SplitIndex), 0o755)
+	}
+	if o.Output != "" {
+		return os.MkdirAll(filepath.Dir(o.Output), 0o755)
+	}
+	return os.MkdirAll(filepath.Dir(o.OutputTemplate), 0o755)
+}
+
+// prune removes the dated snapshot directories older than the retention.
+func (d *daemon) prune() {
+	if d.retention == 0 {
+		return
+	}
+	entries, err := os.ReadDir(d.root)
+	if err != nil {
+		slog.Warn("failed to list snapshots", "dir", d.root, "err", err)
+		return
+	}
+	now := time.Now().In(normalize.CadasturLocation)
+	cutoff := time.Date(now.Year(), now.Month(), now.Day()-d.retention, 0, 0, 0, 0, normalize.CadasturLocation)
+	for _, e := range entries {
+		date, err := time.ParseInLocation(snapshotDateLayout, e.Name(), normalize.CadasturLocation)
+		if !e.IsDir() || err != nil || !date.Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(d.root, e.Name())
+		if err := os.RemoveAll(path); err != nil {
+			slog.Warn("failed to remove old snapshot", "dir", path, "err", err)
+			continue
+		}
+		slog.Info("old snapshot removed", "dir", path)
+		fmt.Println("Snapshot antigo removido:", path)
+	}
+}
+
+// update changes the status of job j.
+func (d *daemon) update(j *daemonJob, change func(*DaemonJobStatus)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i := range d.status.Jobs {
+		if d.status.Jobs[i].Name == j.job.Name {
+			change(&d.status.Jobs[i])
+			return
+		}
+	}
+}
+
+// loadStatus builds the job list of the status, keeping the last runs
+// recorded by a previous daemon in the status file.
+func (d *daemon) loadStatus() {
+	var previous DaemonStatus
+	if b, err := os.ReadFile(d.statusPath); err == nil {
+		if err := json.Unmarshal(b, &previous); err != nil {
+			slog.Warn("ignoring unreadable status file", "path", d.statusPath, "err", err)
+		}
+	}
+	for _, j := range d.jobs {
+		s := DaemonJobStatus{Name: j.job.Name, Schedule: j.schedule.String()}
+		for _, p := range previous.Jobs {
+			if p.Name == s.Name {
+				s.LastRun, s.LastSuccess, s.LastFailure = p.LastRun, p.LastSuccess, p.LastFailure
+			}
+		}
+		d.status.Jobs = append(d.status.Jobs, s)
+	}
+}
+
+// snapshotStatus copies the status with the next run of every job.
+func (d *daemon) snapshotStatus() DaemonStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.status
+	s.Jobs = append([]DaemonJobStatus(nil), d.status.Jobs...)
+	for i, j := range d.jobs {
+		s.Jobs[i].NextRun = j.next
+	}
+	return s
+}
+
+// saveStatus writes the status file atomically.
+func (d *daemon) saveStatus() {
+	d.mu.Lock()
+	d.status.UpdatedAt = time.Now()
+	d.mu.Unlock()
+	b, err := json.MarshalIndent(d.snapshotStatus(), "", "  ")
+	if err == nil {
+		tmp := d.statusPath + ".tmp"
+		if err = os.WriteFile(tmp, append(b, '\n'), 0o644); err == nil {
+			err = os.Rename(tmp, d.statusPath)
+		}
+	}
+	if err != nil {
+		slog.Warn("failed to write status file", "path", d.statusPath, "err", err)
+	}
+}
+
+// serveStatus serves GET /status until the returned stop function is called.
+func (d *daemon) serveStatus(ctx context.Context, addr string) (stop func(), err error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(d.snapshotStatus())
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("status server failed", "err", err)
+		}
+	}()
+	fmt.Printf("Status em http://%s/status\n", ln.Addr())
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}, nil
+}
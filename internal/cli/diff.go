@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cadastur-csv/internal/csvx"
+	"cadastur-csv/internal/diff"
+)
+
+// RunDiff compares two exports (CSV or NDJSON, optionally gzipped) and writes
+// added, removed and changed providers as a report, CSV or JSON.
+// Usage: diff [--key id|numeroCadastro] [--format report|csv|json] [--output f] <antigo> <novo>
+func RunDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	key := fs.String("key", csvx.DefaultKey, "coluna que identifica o prestador: id ou numeroCadastro")
+	format := fs.String("format", "report", "formato do resultado: report, csv ou json")
+	output := fs.String("output", "", "arquivo de saída (padrão: terminal)")
+	fields := fs.String("fields", "", "colunas comparadas, separadas por vírgula (padrão: todas)")
+	ignore := fs.String("ignore", "", "colunas ignoradas, separadas por vírgula")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: diff [--key id|numeroCadastro] [--format report|csv|json] [--output file] <old> <new>")
+	}
+
+	var write func(io.Writer, diff.Result) error
+	switch *format {
+	case "report":
+		write = diff.WriteReport
+	case "csv":
+		write = diff.WriteCSV
+	case "json":
+		write = diff.WriteJSON
+	default:
+		return fmt.Errorf("unknown diff format %q (use report, csv or json)", *format)
+	}
+
+	oldHeader, oldRows, err := readExport(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	newHeader, newRows, err := readExport(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	res, err := diff.Compare(oldHeader, oldRows, newHeader, newRows, diff.Options{
+		Key:    *key,
+		Fields: splitList(*fields),
+		Ignore: splitList(*ignore),
+	})
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create diff output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := write(out, res); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+
+	// Summary counts go to stderr so they never mix with CSV/JSON on stdout.
+	// A report printed to the terminal already starts with them.
+	if *format != "report" || *output != "" {
+		s := res.Summary
+		fmt.Fprintf(os.Stderr, "Novos: %d | Removidos: %d | Alterados: %d | Sem alteração: %d\n", s.Added, s.Removed, s.Changed, s.Unchanged)
+	}
+	return nil
+}
+
+// readExport loads every row of an export file.
+func readExport(path string) ([]string, []map[string]string, error) {
+	r, err := csvx.OpenReader(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer r.Close()
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return r.Header(), rows, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
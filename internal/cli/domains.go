@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+// RunUFs lists the UFs, from the cache when fresh. Works offline through the
+// stale cache or the embedded snapshot.
+// Usage: ufs [--refresh] [--cache-ttl 168h] [--json]
+func RunUFs(ctx context.Context, service *cadastur.Service, args []string) error {
+	domains, asJSON, err := parseDomainFlags("ufs", service, args)
+	if err != nil {
+		return err
+	}
+	ufs, source, err := domains.UFs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch UFs: %w", err)
+	}
+	if asJSON {
+		return printJSON(ufs)
+	}
+	for _, uf := range ufs {
+		fmt.Printf("%d - %s (%s)\n", uf.ID, uf.NoUf, uf.SgUf)
+	}
+	fmt.Fprintf(os.Stderr, "%d UFs (fonte: %s)\n", len(ufs), source)
+	return nil
+}
+
+// RunActivities lists the tourism activities, from the cache when fresh.
+// Usage: activities [--refresh] [--cache-ttl 168h] [--all] [--json]
+func RunActivities(ctx context.Context, service *cadastur.Service, args []string) error {
+	fs := flag.NewFlagSet("activities", flag.ContinueOnError)
+	all := fs.Bool("all", false, "inclui atividades inativas")
+	domains, asJSON, err := parseDomainFlagSet(fs, service, args)
+	if err != nil {
+		return err
+	}
+	acts, source, err := domains.Activities(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch activities: %w", err)
+	}
+	if !*all {
+		active := acts[:0:0]
+		for _, a := range acts {
+			if a.FlAtivo {
+				active = append(active, a)
+			}
+		}
+		acts = active
+	}
+	if asJSON {
+		return printJSON(acts)
+	}
+	for _, a := range acts {
+		fmt.Printf("%d - %s\n", a.NuAtividadeTuristica, a.NoAtividadeTuristica)
+	}
+	fmt.Fprintf(os.Stderr, "%d atividades (fonte: %s)\n", len(acts), source)
+	return nil
+}
+
+func parseDomainFlags(name string, service *cadastur.Service, args []string) (*cadastur.Domains, bool, error) {
+	return parseDomainFlagSet(flag.NewFlagSet(name, flag.ContinueOnError), service, args)
+}
+
+// parseDomainFlagSet adds the cache flags shared by ufs and activities to fs and parses args.
+func parseDomainFlagSet(fs *flag.FlagSet, service *cadastur.Service, args []string) (*cadastur.Domains, bool, error) {
+	domains := cadastur.NewDomains(service)
+	fs.BoolVar(&domains.Refresh, "refresh", false, "consulta a API mesmo com cache válido")
+	fs.DurationVar(&domains.TTL, "cache-ttl", cadastur.DefaultCacheTTL, "validade do cache (ex.: 24h)")
+	asJSON := fs.Bool("json", false, "imprime a lista em JSON")
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+	if fs.NArg() > 0 {
+		return nil, false, errors.New("usage: " + fs.Name() + " [--refresh] [--cache-ttl 168h] [--json]")
+	}
+	return domains, *asJSON, nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// noteFallback tells the user when a domain list did not come from the API
+// or a fresh cache, since it may be outdated.
+func noteFallback(list string, source cadastur.Source) {
+	switch source {
+	case cadastur.SourceStale, cadastur.SourceSnapshot:
+		fmt.Printf("Aviso: API indisponível; usando lista de %s do %s.\n", list, source)
+	}
+}
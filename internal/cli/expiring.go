@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"cadastur-csv/internal/csvx"
+	"cadastur-csv/internal/expiry"
+	"cadastur-csv/internal/normalize"
+)
+
+// RunExpiring lists providers of an export whose registration expires within
+// N days, or already expired while still marked as active, grouped by município.
+// Usage: expiring [--days 30] [--format report|csv|json] [--output f] <exportacao>
+func RunExpiring(args []string) error {
+	fs := flag.NewFlagSet("expiring", flag.ContinueOnError)
+	days := fs.Int("days", 30, "janela em dias até o fim da vigência")
+	key := fs.String("key", csvx.DefaultKey, "coluna que identifica o prestador no relatório: id ou numeroCadastro")
+	format := fs.String("format", "report", "formato do resultado: report, csv ou json")
+	output := fs.String("output", "", "arquivo de saída (padrão: terminal)")
+	today := fs.String("today", "", "data de referência AAAA-MM-DD (padrão: hoje)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: expiring [--days 30] [--format report|csv|json] [--output file] <export>")
+	}
+	if *days < 0 {
+		return fmt.Errorf("--days must not be negative")
+	}
+
+	var write func(io.Writer, expiry.Report) error
+	switch *format {
+	case "report":
+		write = expiry.WriteReport
+	case "csv":
+		write = expiry.WriteCSV
+	case "json":
+		write = expiry.WriteJSON
+	default:
+		return fmt.Errorf("unknown expiring format %q (use report, csv or json)", *format)
+	}
+
+	ref := time.Now()
+	if *today != "" {
+		t, err := time.ParseInLocation("2006-01-02", *today, normalize.CadasturLocation)
+		if err != nil {
+			return fmt.Errorf("invalid --today: %w", err)
+		}
+		ref = t
+	}
+
+	header, rows, err := readExport(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	rep, err := expiry.Build(header, rows, *key, ref, *days)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create report output: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := write(out, rep); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if *format != "report" || *output != "" {
+		fmt.Fprintf(os.Stderr, "A vencer: %d | Vencidos ainda ativos: %d | Municípios: %d\n", rep.Expiring, rep.ExpiredActive, len(rep.Groups))
+	}
+	return nil
+}
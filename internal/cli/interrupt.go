@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ErrInterrupted is returned when a run was stopped by Ctrl-C (or SIGTERM)
+// after writing what it had fetched so far.
+var ErrInterrupted = errors.New("interrupted")
+
+// InterruptContext returns a context canceled on the first Ctrl-C or SIGTERM,
+// so the running command can flush its output and print a partial summary.
+// A second signal exits immediately with status 130. Call stop to release
+// the signal handler.
+func InterruptContext(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\nInterrompendo: gravando o que já foi baixado... (Ctrl-C de novo para sair imediatamente)")
+		cancel()
+		select {
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "Saída forçada.")
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// Checkpoint records how far an interrupted export got, next to the export
+// ("prestadores.checkpoint.json"), so it is clear what the file contains.
+type Checkpoint struct {
+	Output        string    `json:"output"`
+	UF            int       `json:"uf"`
+	Atividade     int       `json:"atividade"`
+	AtividadeNome string    `json:"atividadeNome"`
+	Cidade        string    `json:"cidade"`
+	PageSize      int       `json:"pageSize"`
+	Pages         int       `json:"pages"`
+	Rows          int       `json:"rows"`
+	TotalResults  int       `json:"totalResults"`
+	InterruptedAt time.Time `json:"interruptedAt"`
+}
+
+// writeCheckpoint saves cp as indented JSON.
+func writeCheckpoint(path string, cp Checkpoint) error {
+	b, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
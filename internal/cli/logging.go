@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// LogOptions selects the slog handler for diagnostic logs. Logs go to
+// stderr, so prompts, listings and exports on stdout stay clean.
+type LogOptions struct {
+	// Level is debug, info, warn or error.
+	Level string
+	// Format is text or json.
+	Format string
+}
+
+// Logger builds the logger described by o, writing to w.
+func (o LogOptions) Logger(w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(o.Level)); err != nil {
+		return nil, fmt.Errorf("unknown --log-level %q (use debug, info, warn or error)", o.Level)
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(o.Format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOpts)), nil
+	}
+	return nil, fmt.Errorf("unknown --log-format %q (use text or json)", o.Format)
+}
+
+// extractLogFlags removes --log-level and --log-format from args, so they
+// work with every command, before or after its name.
+func extractLogFlags(args []string) (LogOptions, []string, error) {
+	opts := LogOptions{Level: "warn", Format: "text"}
+	values, rest, err := extractFlags(args, "log-level", "log-format")
+	if err != nil {
+		return opts, nil, err
+	}
+	if v, ok := values["log-level"]; ok {
+		opts.Level = v
+	}
+	if v, ok := values["log-format"]; ok {
+		opts.Format = v
+	}
+	return opts, rest, nil
+}
+
+// extractFlags removes the named string flags (in "--flag value" or
+// "--flag=value" form, with one or two dashes) from args and returns their
+// values. Parsing stops at "--".
+func extractFlags(args []string, names ...string) (map[string]string, []string, error) {
+	values := make(map[string]string)
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !slices.Contains(names, name) {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("flag needs an argument: --%s", name)
+			}
+			i++
+			value = args[i]
+		}
+		values[name] = value
+	}
+	return values, rest, nil
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/config"
+	"cadastur-csv/internal/csvx"
+	"cadastur-csv/internal/normalize"
+)
+
+// Options holds the command-line settings for a run.
+type Options struct {
+	// Output is the export path; empty means a name derived from the activity.
+	Output string
+	// Format is csv or ndjson; empty means inferred from Output (csv by default).
+	Format csvx.Format
+	// Compress gzips the output; implied when Output ends in ".gz".
+	Compress bool
+	// DateFormat renders the vigência dates: iso, br, rfc3339 or epoch.
+	DateFormat normalize.DateFormat
+	// ExtraColumns lists optional column groups appended to the export (see csvx.ExtraColumns).
+	ExtraColumns []string
+	// Transforms maps a column name to a text transform (title, upper, lower, trim).
+	Transforms map[string]string
+	// SplitBy routes rows into one file per distinct value of this column (empty = single file).
+	SplitBy string
+	// SplitTemplate is the file name template for partitions, e.g. "prestadores-{uf}-{municipio}.csv".
+	SplitTemplate string
+	// SplitIndex is the path of the index file listing every partition and its row count.
+	SplitIndex string
+	// MaxOpenFiles bounds how many partition files are kept open at once.
+	MaxOpenFiles int
+	// StatsFormat is json, csv or none for the stats file written next to the export.
+	StatsFormat string
+	// Privacy, when set, redacts pessoa física rows (LGPD profile).
+	Privacy *csvx.Privacy
+	// Refresh asks the API for the UF and activity lists even when the cache is fresh.
+	Refresh bool
+	// CacheTTL is how long the cached UF and activity lists are used.
+	CacheTTL time.Duration
+	// UF and Activity select the filters without prompting (0 = ask).
+	UF       int
+	Activity int
+	// City is the localidadesUfs filter; asked for unless CitySet.
+	City    string
+	CitySet bool
+	// DefaultUF and DefaultActivity are offered by the prompts.
+	DefaultUF       int
+	DefaultActivity int
+	// PageSize is the number of providers per request.
+	PageSize int
+	// Timeout bounds each HTTP request.
+	Timeout time.Duration
+	// OutputTemplate names the export when Output is empty; see outputName.
+	OutputTemplate string
+}
+
+// privacySaltEnv names the environment variable read when --privacy-salt is
+// not given, so the salt does not end up in the shell history.
+var privacySaltEnv = config.EnvName("privacy-salt")
+
+// ParseOptions parses command-line arguments into Options. Each flag can also
+// be set, from lowest to highest precedence, in the [defaults] table of the
+// config file, in the profile chosen with --profile, by its CADASTUR_*
+// environment variable (only the settings in config.EnvSettings), and on the
+// command line.
+func ParseOptions(args []string) (Options, error) {
+	var opts Options
+	var format, extra, dateFormat, transforms, privacyRules, privacySalt string
+	var privacy bool
+
+	settings, args, err := extractFlags(args, "config", "profile")
+	if err != nil {
+		return Options{}, err
+	}
+
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	fs.String("config", "", "arquivo de configuração (padrão: config.toml no diretório de configuração do usuário)")
+	fs.String("profile", "", "perfil do arquivo de configuração (ex.: sc-guias)")
+	fs.IntVar(&opts.UF, "uf", 0, "ID da UF; evita a pergunta (0 = perguntar)")
+	fs.IntVar(&opts.Activity, "activity", 0, "ID da atividade; evita a pergunta (0 = perguntar)")
+	fs.StringVar(&opts.City, "city", "", "filtro de cidade (localidadesUfs); evita a pergunta, vazio = sem filtro")
+	fs.IntVar(&opts.DefaultUF, "default-uf", 24, "UF sugerida na pergunta")
+	fs.IntVar(&opts.DefaultActivity, "default-activity", 29, "atividade sugerida na pergunta")
+	fs.IntVar(&opts.PageSize, "page-size", 1000, "prestadores por requisição")
+	fs.DurationVar(&opts.Timeout, "timeout", 30*time.Second, "tempo limite de cada requisição HTTP")
+	fs.StringVar(&opts.OutputTemplate, "output-template", defaultOutputTemplate, "modelo do nome do arquivo quando --output não é informado ({atividade}, {slug}, {uf}, {data})")
+	fs.StringVar(&opts.Output, "output", "", "arquivo de saída (padrão prestadores-atividade-<ID>-<slug>.csv)")
+	fs.StringVar(&format, "format", "", "formato de saída: csv ou ndjson (padrão: pela extensão do arquivo)")
+	fs.BoolVar(&opts.Compress, "compress", false, "compacta a saída com gzip (implícito quando o arquivo termina em .gz)")
+	fs.StringVar(&dateFormat, "date-format", "iso", "formato das datas de vigência: iso (AAAA-MM-DD), br (DD/MM/AAAA), rfc3339 ou epoch (milissegundos)")
+	fs.StringVar(&extra, "extra-columns", "", "grupos de colunas adicionais, separados por vírgula (ex.: telefone)")
+	fs.StringVar(&transforms, "transform", "", "transformações por coluna, ex.: nomePrestador=title,logradouro=title,bairro=title")
+	fs.StringVar(&opts.SplitBy, "split-by", "", "gera um arquivo por valor desta coluna (ex.: municipio, situacao)")
+	fs.StringVar(&opts.SplitTemplate, "split-template", "", "modelo do nome dos arquivos (padrão prestadores-{uf}-{<coluna>}.csv)")
+	fs.StringVar(&opts.SplitIndex, "split-index", "prestadores-index.csv", "arquivo de índice com as partições e a contagem de linhas")
+	fs.IntVar(&opts.MaxOpenFiles, "max-open-files", csvx.DefaultMaxOpenFiles, "máximo de arquivos de partição abertos ao mesmo tempo")
+	fs.StringVar(&opts.StatsFormat, "stats", "json", "arquivo de estatísticas ao lado da exportação: json, csv ou none")
+	fs.BoolVar(&opts.Refresh, "refresh", false, "atualiza as listas de UFs e atividades mesmo com cache válido")
+	fs.DurationVar(&opts.CacheTTL, "cache-ttl", cadastur.DefaultCacheTTL, "validade do cache de UFs e atividades (ex.: 24h)")
+	fs.BoolVar(&privacy, "privacy", false, "modo LGPD: oculta dados pessoais das linhas de pessoa física")
+	fs.StringVar(&privacyRules, "privacy-rules", "", "regras do modo LGPD por coluna (keep, redact, mask, hash), ex.: nomePrestador=hash,cep=keep")
+	fs.StringVar(&privacySalt, "privacy-salt", "", "segredo dos hashes do modo LGPD (padrão: variável "+privacySaltEnv+")")
+
+	for _, name := range []string{"config", "profile"} {
+		if _, ok := settings[name]; !ok {
+			settings[name] = os.Getenv(config.EnvName(name))
+		}
+	}
+	if err := applySettings(fs, settings["config"], settings["profile"]); err != nil {
+		return Options{}, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return Options{}, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "city" {
+			opts.CitySet = true
+		}
+	})
+	// Choosing both UF and activity means an unattended run: don't ask for the city.
+	if opts.UF > 0 && opts.Activity > 0 {
+		opts.CitySet = true
+	}
+	if opts.PageSize <= 0 {
+		return Options{}, fmt.Errorf("--page-size must be positive")
+	}
+	if opts.Timeout <= 0 {
+		return Options{}, fmt.Errorf("--timeout must be positive")
+	}
+	if fs.NArg() > 0 {
+		return Options{}, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if format != "" {
+		f, err := csvx.ParseFormat(format)
+		if err != nil {
+			return Options{}, err
+		}
+		opts.Format = f
+	}
+	df, err := normalize.ParseDateFormat(dateFormat)
+	if err != nil {
+		return Options{}, err
+	}
+	opts.DateFormat = df
+	opts.ExtraColumns = splitList(extra)
+	columns, err := csvx.ColumnsWith(opts.ExtraColumns)
+	if err != nil {
+		return Options{}, err
+	}
+	if opts.Transforms, err = csvx.ParseTransforms(transforms); err != nil {
+		return Options{}, err
+	}
+	if _, err := csvx.WithTransforms(columns, opts.Transforms); err != nil {
+		return Options{}, err
+	}
+	switch opts.StatsFormat {
+	case "json", "csv", "none":
+	default:
+		return Options{}, fmt.Errorf("unknown --stats format %q (use json, csv or none)", opts.StatsFormat)
+	}
+	if opts.Privacy, err = parsePrivacy(privacy, privacyRules, privacySalt); err != nil {
+		return Options{}, err
+	}
+	if opts.SplitBy != "" {
+		if _, ok := csvx.ColumnByName(opts.SplitBy); !ok {
+			return Options{}, fmt.Errorf("unknown --split-by column %q", opts.SplitBy)
+		}
+		// Without the split column in the name, partitions would share files.
+		if opts.SplitTemplate != "" && !strings.Contains(opts.SplitTemplate, "{"+opts.SplitBy+"}") {
+			return Options{}, fmt.Errorf("--split-template %q must contain {%s}", opts.SplitTemplate, opts.SplitBy)
+		}
+		if opts.Output != "" {
+			return Options{}, fmt.Errorf("--output cannot be used with --split-by (use --split-template)")
+		}
+	}
+
+	return opts, nil
+}
+
+// parsePrivacy builds the LGPD settings of --privacy, --privacy-rules and
+// --privacy-salt; it returns nil when neither of the first two was given.
+func parsePrivacy(enabled bool, rules, salt string) (*csvx.Privacy, error) {
+	if !enabled && rules == "" {
+		return nil, nil
+	}
+	parsed, err := csvx.ParsePrivacyRules(rules)
+	if err != nil {
+		return nil, err
+	}
+	if salt == "" {
+		salt = os.Getenv(privacySaltEnv)
+	}
+	if salt == "" {
+		return nil, fmt.Errorf("--privacy needs a salt: set --privacy-salt or %s", privacySaltEnv)
+	}
+	return &csvx.Privacy{Salt: salt, Rules: parsed}, nil
+}
+
+// applySettings sets flag values from the config file defaults, the selected
+// profile and the CADASTUR_* variables of config.EnvSettings, in that order,
+// before the command line is parsed (so flags still win).
+func applySettings(fs *flag.FlagSet, configPath, profile string) error {
+	file, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	if err := setFlags(fs, file.Defaults, "[defaults]"); err != nil {
+		return err
+	}
+	if profile != "" {
+		values, err := file.Profile(profile)
+		if err != nil {
+			return err
+		}
+		if err := setFlags(fs, values, "profile "+profile); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range config.EnvSettings {
+		if name == "config" || name == "profile" || fs.Lookup(name) == nil {
+			continue
+		}
+		if v, ok := os.LookupEnv(config.EnvName(name)); ok {
+			if err := fs.Set(name, v); err != nil {
+				return fmt.Errorf("invalid %s: %w", config.EnvName(name), err)
+			}
+		}
+	}
+	return nil
+}
+
+// setFlags applies config values to the flags of the same name.
+func setFlags(fs *flag.FlagSet, values map[string]string, origin string) error {
+	for name, v := range values {
+		if name == "config" || name == "profile" || fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown setting %q in %s", name, origin)
+		}
+		if err := fs.Set(name, v); err != nil {
+			return fmt.Errorf("invalid %s in %s: %w", name, origin, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseOptionsPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	err := os.WriteFile(path, []byte(`
+[defaults]
+uf = 1
+activity = 1
+default-uf = 1
+output-template = "defaults-{uf}"
+page-size = 100
+
+[profiles.p]
+uf = 2
+activity = 2
+output-template = "perfil-{uf}"
+format = "csv"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		uf       int
+		activity int
+		template string
+	}{
+		{"defaults", nil, nil, 1, 1, "defaults-{uf}"},
+		{"profile over defaults", nil, []string{"--profile", "p"}, 2, 2, "perfil-{uf}"},
+		{"env over profile", map[string]string{"CADASTUR_UF": "3", "CADASTUR_OUTPUT_TEMPLATE": "env-{uf}"}, []string{"--profile", "p"}, 3, 2, "env-{uf}"},
+		{"flags over env", map[string]string{"CADASTUR_UF": "3", "CADASTUR_ACTIVITY": "3"}, []string{"--profile", "p", "--uf", "4"}, 4, 3, "perfil-{uf}"},
+		{"profile from env", map[string]string{"CADASTUR_PROFILE": "p"}, nil, 2, 2, "perfil-{uf}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, v := range tt.env {
+				t.Setenv(name, v)
+			}
+			opts, err := ParseOptions(append([]string{"--config", path}, tt.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.UF != tt.uf || opts.Activity != tt.activity || opts.OutputTemplate != tt.template {
+				t.Errorf("uf, activity, output-template = %d, %d, %q, want %d, %d, %q",
+					opts.UF, opts.Activity, opts.OutputTemplate, tt.uf, tt.activity, tt.template)
+			}
+		})
+	}
+}
+
+func TestParseOptionsEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CADASTUR_DEFAULT_UF", "19")
+	t.Setenv("CADASTUR_DEFAULT_ACTIVITY", "31")
+	t.Setenv("CADASTUR_OUTPUT", "saida.csv")
+	t.Setenv("CADASTUR_PAGE_SIZE", "500")
+	// Not in config.EnvSettings: ignored.
+	t.Setenv("CADASTUR_FORMAT", "ndjson")
+	t.Setenv("CADASTUR_EXTRA_COLUMNS", "telefone")
+
+	opts, err := ParseOptions([]string{"--config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.DefaultUF != 19 || opts.DefaultActivity != 31 || opts.Output != "saida.csv" || opts.PageSize != 500 {
+		t.Errorf("ParseOptions = %+v, want the CADASTUR_* values", opts)
+	}
+	if opts.Format != "" || len(opts.ExtraColumns) != 0 {
+		t.Errorf("format, extra columns = %q, %v, want them unset", opts.Format, opts.ExtraColumns)
+	}
+
+	t.Setenv("CADASTUR_UF", "x")
+	if _, err := ParseOptions([]string{"--config", path}); err == nil {
+		t.Error("ParseOptions accepted CADASTUR_UF=x")
+	}
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressLogInterval spaces the progress lines printed when the output is
+// not a terminal, so redirected logs are not flooded with one line per page.
+const progressLogInterval = 10 * time.Second
+
+// Progress reports paging progress: a live bar with rows/total, pages,
+// throughput and ETA on a terminal, or a periodic line otherwise.
+type Progress struct {
+	w       io.Writer
+	tty     bool
+	start   time.Time
+	lastLog time.Time
+	logged  int // pages count at the last printed line
+	total   int
+	rows    int
+	pages   int
+}
+
+// NewProgress creates a Progress writing to f, drawing a bar when f is a terminal.
+func NewProgress(f *os.File) *Progress {
+	return &Progress{w: f, tty: isTerminal(f), start: time.Now()}
+}
+
+// isTerminal reports whether f is a character device (a terminal, not a file or pipe).
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Page records one fetched page. total is the TotalResults of the response
+// (-1 or 0 when unknown).
+func (p *Progress) Page(rows, total int) {
+	p.rows += rows
+	p.pages++
+	if total > 0 {
+		p.total = total
+	}
+	if p.tty {
+		fmt.Fprint(p.w, "\r"+p.line(true)+"\x1b[K")
+		return
+	}
+	if now := time.Now(); p.pages == 1 || now.Sub(p.lastLog) >= progressLogInterval {
+		p.lastLog, p.logged = now, p.pages
+		fmt.Fprintln(p.w, p.line(false))
+	}
+}
+
+// Done ends the bar line, or prints the last progress line when it was not
+// printed yet.
+func (p *Progress) Done() {
+	if p.pages == 0 {
+		return
+	}
+	if p.tty {
+		fmt.Fprintln(p.w)
+		return
+	}
+	if p.logged != p.pages {
+		fmt.Fprintln(p.w, p.line(false))
+	}
+}
+
+// line renders the current state, with a bar when bar is true.
+func (p *Progress) line(bar bool) string {
+	elapsed := time.Since(p.start)
+	rate := 0.0
+	if s := elapsed.Seconds(); s > 0 {
+		rate = float64(p.rows) / s
+	}
+
+	var b strings.Builder
+	if p.total > 0 {
+		share := float64(p.rows) / float64(p.total)
+		if share > 1 {
+			share = 1
+		}
+		if bar {
+			const width = 30
+			filled := int(share * width)
+			b.WriteString("[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "] ")
+		}
+		fmt.Fprintf(&b, "%d/%d (%.0f%%)", p.rows, p.total, share*100)
+	} else {
+		fmt.Fprintf(&b, "%d linhas", p.rows)
+	}
+	fmt.Fprintf(&b, " | páginas: %d | %.0f linhas/s", p.pages, rate)
+	if p.total > p.rows && rate > 0 {
+		eta := time.Duration(float64(p.total-p.rows) / rate * float64(time.Second))
+		fmt.Fprintf(&b, " | ETA %s", eta.Round(time.Second))
+	} else {
+		fmt.Fprintf(&b, " | %s", elapsed.Round(time.Second))
+	}
+	return b.String()
+}
@@ -17,8 +17,9 @@ func section(title string) {
 }
 
 // PromptUF displays available UFs and prompts the user to select one.
-// Returns the selected UF ID, defaulting to 24 (Santa Catarina) if input is invalid.
-func PromptUF(ufs []cadastur.UF) (int, error) {
+// Returns the selected UF ID, defaulting to def (24, Santa Catarina, unless
+// configured otherwise) if input is invalid.
+func PromptUF(ufs []cadastur.UF, def int) (int, error) {
 	section("Selecione um Estado (UF)")
 	fmt.Println("UFs disponíveis:")
 	for _, uf := range ufs {
@@ -27,27 +28,28 @@ func PromptUF(ufs []cadastur.UF) (int, error) {
 
 	// Ask the user to choose a UF using line-based input to avoid scanf issues
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("▶ Digite o ID da UF (padrão 24 para Santa Catarina): ")
+	fmt.Printf("▶ Digite o ID da UF (padrão %d para %s): ", def, ufName(ufs, def))
 	line, err := reader.ReadString('\n')
 	if err != nil {
 		// On read error, fall back to default
-		return 24, nil
+		return def, nil
 	}
 	line = strings.TrimSpace(line)
 	if line == "" {
-		return 24, nil
+		return def, nil
 	}
 	v, err := strconv.Atoi(line)
 	if err != nil {
-		return 24, nil
+		return def, nil
 	}
 	fmt.Println("UF selecionada:", v)
 	return v, nil
 }
 
 // PromptActivity displays available activities and prompts the user to select one.
-// Returns the selected activity ID and name, defaulting to 29 (Guia de Turismo) if input is invalid.
-func PromptActivity(activities []cadastur.Activity) (int, string, error) {
+// Returns the selected activity ID and name, defaulting to def (29, Guia de
+// Turismo, unless configured otherwise) if input is invalid.
+func PromptActivity(activities []cadastur.Activity, def int) (int, string, error) {
 	section("Selecione uma Atividade Turística")
 	fmt.Println("Atividades disponíveis (somente ativas):")
 	for _, a := range activities {
@@ -56,40 +58,55 @@ func PromptActivity(activities []cadastur.Activity) (int, string, error) {
 		}
 	}
 
+	// Map ID -> Name (fallback to the default activity, then 'Guia de Turismo', if not found)
+	defName, ok := activityName(activities, def)
+	if !ok {
+		defName = "Guia de Turismo"
+	}
+
 	// Ask the user to choose an activity (by ID) using line-based input
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("▶ Digite o ID da atividade (padrão 29 para Guia de Turismo): ")
+	fmt.Printf("▶ Digite o ID da atividade (padrão %d para %s): ", def, defName)
 	line, err := reader.ReadString('\n')
 	if err != nil {
 		// fallback to default
-		return 29, "Guia de Turismo", nil
+		return def, defName, nil
 	}
 	line = strings.TrimSpace(line)
-	var selectedActID int
-	if line == "" {
-		selectedActID = 29
-	} else {
-		v, err := strconv.Atoi(line)
-		if err != nil {
-			selectedActID = 29
-		} else {
-			selectedActID = v
-		}
+	selectedActID := def
+	if v, err := strconv.Atoi(line); err == nil {
+		selectedActID = v
 	}
 
-	// Map ID -> Name (fallback to 'Guia de Turismo' if not found)
-	selectedActName := "Guia de Turismo"
-	for _, a := range activities {
-		if a.NuAtividadeTuristica == selectedActID {
-			selectedActName = a.NoAtividadeTuristica
-			break
-		}
+	selectedActName, ok := activityName(activities, selectedActID)
+	if !ok {
+		selectedActName = defName
 	}
 	fmt.Println("Atividade selecionada:", selectedActID, "-", selectedActName)
 
 	return selectedActID, selectedActName, nil
 }
 
+// activityName returns the name of the activity with the given ID.
+func activityName(activities []cadastur.Activity, id int) (string, bool) {
+	for _, a := range activities {
+		if int(a.NuAtividadeTuristica) == id {
+			return string(a.NoAtividadeTuristica), true
+		}
+	}
+	return "", false
+}
+
+// ufName returns the name of the UF with the given ID, or "UF <id>".
+func ufName(ufs []cadastur.UF, id int) string {
+	for _, uf := range ufs {
+		if int(uf.ID) == id {
+			return string(uf.NoUf)
+		}
+	}
+	return fmt.Sprintf("UF %d", id)
+}
+
 // PromptCity prompts the user for an optional city input.
 // Returns the city string (can be empty), with a Portuguese warning about leaving it blank.
 func PromptCity() (string, error) {
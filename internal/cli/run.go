@@ -3,51 +3,139 @@ package cli
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"cadastur-csv/internal/cadastur"
 	"cadastur-csv/internal/csvx"
 	"cadastur-csv/internal/normalize"
+	"cadastur-csv/internal/stats"
 )
 
 // Run orchestrates the full workflow: prompts → API → CSV writer → summary.
-func Run(ctx context.Context, service *cadastur.Service) error {
-	// 1) Fetch UFs and prompt the user to select a state (with default).
-	ufs, err := service.FetchUFs(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to fetch UFs: %w", err)
-	}
+func Run(ctx context.Context, service *cadastur.Service, opts Options) error {
+	domains := cadastur.NewDomains(service)
+	domains.TTL, domains.Refresh = opts.CacheTTL, opts.Refresh
+	return runExport(ctx, service, domains, opts, &Result{})
+}
 
-	selectedUF, err := PromptUF(ufs)
-	if err != nil {
-		return fmt.Errorf("failed to prompt UF: %w", err)
+// Result describes what an export wrote, for batch reports.
+type Result struct {
+	// Output is the export file, or the index file of a split export.
+	Output       string `json:"output"`
+	Rows         int    `json:"rows"`
+	Pages        int    `json:"pages"`
+	TotalResults int    `json:"totalResults"`
+}
+
+// runExport is Run with the domain lists and the result supplied by the
+// caller, so batch jobs share one cache and collect per-job counts.
+func runExport(ctx context.Context, service *cadastur.Service, domains *cadastur.Domains, opts Options, res *Result) error {
+	service.SetTimeout(opts.Timeout)
+
+	// 1) Load UFs (cache, API or embedded snapshot) and prompt the user to select a state (with default).
+	selectedUF := opts.UF
+	if selectedUF == 0 {
+		ufs, source, err := domains.UFs(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ErrInterrupted
+			}
+			return fmt.Errorf("failed to fetch UFs: %w", err)
+		}
+		noteFallback("UFs", source)
+
+		if selectedUF, err = PromptUF(ufs, opts.DefaultUF); err != nil {
+			return fmt.Errorf("failed to prompt UF: %w", err)
+		}
 	}
 
 	// 2) Fetch activities and prompt the user to select one (with default).
-	acts, err := service.FetchActivities(ctx)
+	// The activity list is needed even with --activity: the API filters by name.
+	acts, source, err := domains.Activities(ctx)
 	if err != nil {
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
 		return fmt.Errorf("failed to fetch activities: %w", err)
 	}
 
-	selectedActID, selectedActName, err := PromptActivity(acts)
-	if err != nil {
-		return fmt.Errorf("failed to prompt activity: %w", err)
+	noteFallback("atividades", source)
+
+	selectedActID, selectedActName := opts.Activity, ""
+	if selectedActID == 0 {
+		selectedActID, selectedActName, err = PromptActivity(acts, opts.DefaultActivity)
+		if err != nil {
+			return fmt.Errorf("failed to prompt activity: %w", err)
+		}
+	} else {
+		name, ok := activityName(acts, selectedActID)
+		if !ok {
+			return fmt.Errorf("unknown activity %d (see the activities command)", selectedActID)
+		}
+		selectedActName = name
 	}
 
-	// Build a descriptive CSV filename based on the chosen activity.
-	fileName := fmt.Sprintf("prestadores-atividade-%d-%s.csv", selectedActID, normalize.Slugify(selectedActName))
+	// Build a descriptive filename based on the chosen activity, unless --output was given.
+	columns, err := csvx.ColumnsWith(opts.ExtraColumns)
+	if err != nil {
+		return err
+	}
+	columns = csvx.WithDateFormat(columns, opts.DateFormat)
+	if columns, err = csvx.WithTransforms(columns, opts.Transforms); err != nil {
+		return err
+	}
+	if opts.Privacy != nil {
+		columns = csvx.WithPrivacy(columns, *opts.Privacy)
+		fmt.Println("Modo LGPD (pessoa física):", strings.Join(csvx.PrivacyColumns(opts.Privacy.Rules, columns), ", "))
+	}
+	outOpts := csvx.Options{Format: opts.Format, Compress: opts.Compress, Columns: columns}
+	fileName := opts.Output
+	if fileName == "" {
+		fileName = outputName(opts.OutputTemplate, selectedUF, selectedActID, selectedActName, time.Now()) + csvx.Extension(outOpts)
+	} else if opts.Compress && !csvx.IsCompressedPath(fileName) {
+		fileName += ".gz"
+	}
 
 	// 3) Prompt for optional city (free-text). Leaving it blank is recommended for broader results.
-	localidadesUfs, err := PromptCity()
-	if err != nil {
-		return fmt.Errorf("failed to prompt city: %w", err)
+	localidadesUfs := opts.City
+	if !opts.CitySet {
+		if localidadesUfs, err = PromptCity(); err != nil {
+			return fmt.Errorf("failed to prompt city: %w", err)
+		}
+	}
+	if ctx.Err() != nil {
+		return ErrInterrupted
 	}
 
-	// Prepare CSV writer (single file) — header is written once.
-	section(fmt.Sprintf("Salvando CSV em %s", fileName))
-	csvWriter, err := csvx.NewWriter(fileName)
-	if err != nil {
-		return fmt.Errorf("failed to create CSV writer: %w", err)
+	// Prepare CSV writer (single file, or one file per partition) — header is written once per file.
+	var csvWriter csvx.RowWriter
+	if opts.SplitBy != "" {
+		split, err := csvx.NewSplitWriter(csvx.SplitConfig{
+			Column:       opts.SplitBy,
+			Template:     opts.SplitTemplate,
+			IndexPath:    opts.SplitIndex,
+			MaxOpenFiles: opts.MaxOpenFiles,
+			Output:       outOpts,
+			Privacy:      opts.Privacy,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create split writer: %w", err)
+		}
+		section(fmt.Sprintf("Salvando arquivos por %s (índice em %s)", opts.SplitBy, split.IndexPath()))
+		csvWriter = split
+	} else {
+		section(fmt.Sprintf("Salvando em %s", fileName))
+		w, err := csvx.Create(fileName, outOpts)
+		if err != nil {
+			return fmt.Errorf("failed to create output writer: %w", err)
+		}
+		csvWriter = w
 	}
 	defer csvWriter.Close()
 
@@ -57,7 +145,7 @@ func Run(ctx context.Context, service *cadastur.Service) error {
 	}
 
 	// 4) Pagination loop — keep fetching pages until the last page is smaller than pageSize.
-	pageSize := 1000
+	pageSize := opts.PageSize
 	totalFetched := 0
 	pages := 0
 	totalExpected := -1
@@ -65,23 +153,41 @@ func Run(ctx context.Context, service *cadastur.Service) error {
 	// Keep a few samples to show after
 	samples := make([]cadastur.Prestador, 0, 5)
 
+	// Aggregate breakdowns for the summary and the stats file.
+	collector := stats.NewCollector()
+
+	// Type coercions reported while decoding, counted per field; the first
+	// few are kept to show in the summary.
+	warnings := map[string]int{}
+	var warningSamples []cadastur.Warning
+	ctx = cadastur.WithWarningHandler(ctx, func(w cadastur.Warning) {
+		warnings[w.Field]++
+		if len(warningSamples) < 5 {
+			warningSamples = append(warningSamples, w)
+		}
+	})
+
 	// Build filters
 	filters := cadastur.BuildFilters(selectedUF, selectedActName, localidadesUfs)
 
+	// Live progress on a terminal, periodic lines when stdout is redirected.
+	progress := NewProgress(os.Stdout)
+
 	// Fetch all pages
 	err = service.FetchPrestadoresPaged(ctx, filters, pageSize, func(prestadores []cadastur.Prestador, currentPage, totalResults int) error {
 		if totalExpected == -1 {
 			totalExpected = totalResults
 		}
 
-		// Status per page (simulating HTTP status for consistency with original)
-		fmt.Printf("Página %d — HTTP: %s — recebidos: %d\n", currentPage, http.StatusText(http.StatusOK), len(prestadores))
+		progress.Page(len(prestadores), totalResults)
 
 		// Append each provider as one CSV row, normalizing phone/CEP.
 		for _, p := range prestadores {
 			if len(samples) < 5 {
 				samples = append(samples, p)
 			}
+			collector.Add(p)
+			csvx.CountRepairs(p, collector.AddRepair)
 			if err := csvWriter.WriteRow(p); err != nil {
 				return fmt.Errorf("failed to write CSV row: %w", err)
 			}
@@ -98,21 +204,154 @@ func Run(ctx context.Context, service *cadastur.Service) error {
 		return nil
 	})
 
-	if err != nil {
+	progress.Done()
+	*res = Result{Output: fileName, Rows: totalFetched, Pages: pages, TotalResults: totalExpected}
+	// On Ctrl-C the page in flight is either fully written or discarded;
+	// everything before it is kept and summarized below.
+	interrupted := err != nil && ctx.Err() != nil
+	if err != nil && !interrupted {
 		return fmt.Errorf("failed to fetch prestadores: %w", err)
 	}
 
+	// Close explicitly so the split index is written before the summary.
+	if err := csvWriter.Close(); err != nil {
+		return fmt.Errorf("failed to close CSV writer: %w", err)
+	}
+
+	statsBase := fileName
+	if split, ok := csvWriter.(*csvx.SplitWriter); ok {
+		statsBase = split.IndexPath()
+	}
+	res.Output = statsBase
+
 	// 5) Final summary and a small sample for visual verification in the terminal.
-	section("Resumo")
+	if interrupted {
+		slog.WarnContext(ctx, "export interrupted", "output", fileName, "split", opts.SplitBy, "rows", totalFetched, "pages", pages, "total", totalExpected)
+		section("Resumo parcial (interrompido)")
+	} else {
+		slog.InfoContext(ctx, "export finished", "output", fileName, "split", opts.SplitBy, "rows", totalFetched, "pages", pages, "total", totalExpected)
+		section("Resumo")
+	}
 	fmt.Printf("Total de resultados: %d | Páginas: %d | Retornados: %d\n", totalExpected, pages, totalFetched)
+	if split, ok := csvWriter.(*csvx.SplitWriter); ok {
+		fmt.Printf("Arquivos gerados: %d | Índice: %s\n", split.Partitions(), split.IndexPath())
+	}
+	if interrupted {
+		checkpointPath := sidecarName(statsBase, ".checkpoint.json")
+		err := writeCheckpoint(checkpointPath, Checkpoint{
+			Output:        statsBase,
+			UF:            selectedUF,
+			Atividade:     selectedActID,
+			AtividadeNome: selectedActName,
+			Cidade:        localidadesUfs,
+			PageSize:      pageSize,
+			Pages:         pages,
+			Rows:          totalFetched,
+			TotalResults:  totalExpected,
+			InterruptedAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+		fmt.Println("Checkpoint salvo em", checkpointPath)
+	}
 
-	// Show first samples
-	max := len(samples)
-	for i := 0; i < max; i++ {
-		p := samples[i]
-		fmt.Printf("%d) %s | %s | %s\n", i+1, p.NomePrestador, p.Municipio, p.NuTelefone)
+	if len(warnings) > 0 {
+		printWarnings(warnings, warningSamples)
 	}
 
+	summary := collector.Stats()
+	stats.Print(os.Stdout, summary, 10)
+	if opts.StatsFormat != "none" {
+		statsPath := statsFileName(statsBase, opts.StatsFormat)
+		if err := writeStats(statsPath, opts.StatsFormat, summary); err != nil {
+			return fmt.Errorf("failed to write stats: %w", err)
+		}
+		fmt.Println("Estatísticas salvas em", statsPath)
+	}
+
+	// Show first samples, rendered like the export (privacy mode included).
+	var sampleColumns []csvx.Column
+	for _, name := range []string{"nomePrestador", "municipio", "telefone"} {
+		c, _ := csvx.FindColumn(columns, opts.Privacy, name)
+		sampleColumns = append(sampleColumns, c)
+	}
+	for i, p := range samples {
+		fmt.Printf("%d) %s | %s | %s\n", i+1, sampleColumns[0].Value(p), sampleColumns[1].Value(p), sampleColumns[2].Value(p))
+	}
+
+	if interrupted {
+		return fmt.Errorf("%w after %d rows in %d pages", ErrInterrupted, totalFetched, pages)
+	}
 	return nil
 }
 
+// defaultOutputTemplate is the export name used when --output is not given;
+// the extension follows --format and --compress.
+const defaultOutputTemplate = "prestadores-atividade-{atividade}-{slug}"
+
+// outputName fills an output template: {atividade} is the activity ID,
+// {slug} its slugified name, {uf} the UF ID and {data} the run date (AAAA-MM-DD).
+func outputName(template string, uf, activity int, activityName string, now time.Time) string {
+	return strings.NewReplacer(
+		"{atividade}", strconv.Itoa(activity),
+		"{slug}", normalize.Slugify(activityName),
+		"{uf}", strconv.Itoa(uf),
+		"{data}", now.In(normalize.CadasturLocation).Format("2006-01-02"),
+	).Replace(template)
+}
+
+// statsFileName places the stats file next to an export:
+// "prestadores.csv.gz" -> "prestadores.stats.json".
+func statsFileName(exportPath, format string) string {
+	return sidecarName(exportPath, ".stats."+format)
+}
+
+// sidecarName replaces the extension of an export (including ".gz") with
+// suffix: ("prestadores.csv.gz", ".checkpoint.json") -> "prestadores.checkpoint.json".
+func sidecarName(exportPath, suffix string) string {
+	base := strings.TrimSuffix(exportPath, ".gz")
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return base + suffix
+}
+
+// writeStats writes the aggregated stats as JSON or CSV.
+func writeStats(path, format string, s stats.Stats) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	write := stats.WriteJSON
+	if format == "csv" {
+		write = stats.WriteCSV
+	}
+	if err := write(f, s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// printWarnings summarizes the type coercions made while decoding providers.
+func printWarnings(counts map[string]int, samples []cadastur.Warning) {
+	fields := make([]string, 0, len(counts))
+	total := 0
+	for f, n := range counts {
+		fields = append(fields, f)
+		total += n
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		name := f
+		if name == "" {
+			name = "registros ignorados"
+		}
+		parts[i] = fmt.Sprintf("%s=%d", name, counts[f])
+	}
+	fmt.Printf("Avisos de tipo: %d (%s)\n", total, strings.Join(parts, ", "))
+	for _, w := range samples {
+		fmt.Println("  -", w)
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/server"
+)
+
+// RunServe serves the UF and activity lists, streamed exports (see
+// internal/server and GET /openapi.yaml) and the export web page over HTTP
+// until ctx is canceled. Requests and exports in flight when the server
+// stops are canceled, which also stops their upstream calls.
+// Usage: serve [--addr :8080] [--rate 2] [--page-size 1000] [--privacy]
+func RunServe(ctx context.Context, service *cadastur.Service, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "endereço de escuta (host:porta)")
+	rate := fs.Float64("rate", 2, "máximo de requisições por segundo à API, somando todas as exportações (0 = sem limite)")
+	pageSize := fs.Int("page-size", 1000, "prestadores por requisição")
+	timeout := fs.Duration("timeout", 30*time.Second, "tempo limite de cada requisição HTTP à API")
+	refresh := fs.Bool("refresh", false, "atualiza as listas de UFs e atividades mesmo com cache válido")
+	cacheTTL := fs.Duration("cache-ttl", cadastur.DefaultCacheTTL, "validade do cache de UFs e atividades")
+	privacy := fs.Bool("privacy", false, "modo LGPD: oculta dados pessoais das linhas de pessoa física em todas as exportações")
+	privacyRules := fs.String("privacy-rules", "", "regras do modo LGPD por coluna (keep, redact, mask, hash)")
+	privacySalt := fs.String("privacy-salt", "", "segredo dos hashes do modo LGPD (padrão: variável "+privacySaltEnv+")")
+	exportDir := fs.String("export-dir", "", "diretório dos arquivos gerados pela página web (padrão: diretório temporário, apagado ao encerrar)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("usage: serve [--addr localhost:8080] [--rate 2] [--page-size 1000] [--privacy]")
+	}
+	if *pageSize <= 0 {
+		return fmt.Errorf("--page-size must be positive")
+	}
+	if *timeout <= 0 {
+		return fmt.Errorf("--timeout must be positive")
+	}
+	p, err := parsePrivacy(*privacy, *privacyRules, *privacySalt)
+	if err != nil {
+		return err
+	}
+
+	service.SetTimeout(*timeout)
+	service.SetRateLimit(*rate)
+	domains := cadastur.NewDomains(service)
+	domains.TTL, domains.Refresh = *cacheTTL, *refresh
+
+	dir := *exportDir
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "cadastur-csv-exports-"); err != nil {
+			return fmt.Errorf("failed to create export dir: %w", err)
+		}
+		defer os.RemoveAll(dir)
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	handler := server.New(service, domains, server.Config{PageSize: *pageSize, Privacy: p, ExportDir: dir})
+	defer handler.Close()
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	fmt.Printf("Página de exportação em http://%s/ (API descrita em /openapi.yaml). Ctrl-C para encerrar.\n", ln.Addr())
+	if p != nil {
+		fmt.Println("Modo LGPD ativo em todas as exportações.")
+	}
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+	fmt.Println("Servidor encerrado.")
+	return nil
+}
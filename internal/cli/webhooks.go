@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"cadastur-csv/internal/config"
+	"cadastur-csv/internal/diff"
+	"cadastur-csv/internal/webhook"
+)
+
+// notifyTimeout bounds the deliveries of one job, retries included, so slow
+// receivers cannot hold up the next job.
+const notifyTimeout = 30 * time.Second
+
+// notifier sends the webhooks of a manifest after each job.
+type notifier struct {
+	manifest string
+	source   string
+	hooks    []*webhook.Hook
+}
+
+// newNotifier reads the [webhooks.<name>] tables of a manifest. source
+// ("batch" or "daemon") is reported in the payloads. Batch runs are never
+// compared with a previous one, so a batch hook cannot ask for changes and
+// hooks on every event leave it out.
+func newNotifier(m *config.Manifest, source string) (*notifier, error) {
+	n := &notifier{manifest: m.Path, source: source}
+	for _, w := range m.Webhooks {
+		h, err := webhook.ParseHook(w.Name, w.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", w.Name, err)
+		}
+		if source == "batch" && slices.Contains(h.Events, webhook.EventChanges) {
+			if _, explicit := w.Settings["events"]; explicit {
+				return nil, fmt.Errorf("webhook %s: the %q event is only sent by the daemon", w.Name, webhook.EventChanges)
+			}
+			h.Events = slices.DeleteFunc(slices.Clone(h.Events), func(e string) bool { return e == webhook.EventChanges })
+		}
+		n.hooks = append(n.hooks, h)
+	}
+	return n, nil
+}
+
+// wantsChanges reports whether a hook listens for changes in job, so the
+// run has to be compared with the previous one.
+func (n *notifier) wantsChanges(job string) bool {
+	for _, h := range n.hooks {
+		if h.Wants(job, []string{webhook.EventChanges}) {
+			return true
+		}
+	}
+	return false
+}
+
+// notify sends the outcome of a job to the hooks subscribed to its events,
+// all at once and within notifyTimeout. changes is nil when the run was not
+// compared. Interrupted runs are not reported, and a failed delivery never
+// fails the job.
+func (n *notifier) notify(ctx context.Context, jr JobReport, changes *webhook.Changes) {
+	if len(n.hooks) == 0 || ctx.Err() != nil {
+		return
+	}
+	events := []string{webhook.EventSuccess}
+	if jr.Status != JobOK {
+		events = []string{webhook.EventFailure}
+	}
+	if changes != nil && changes.Summary.Added+changes.Summary.Removed > 0 {
+		events = append(events, webhook.EventChanges)
+	}
+	p := webhook.Payload{
+		Events:   events,
+		Source:   n.source,
+		Manifest: n.manifest,
+		Run:      webhookRun(jr),
+		Changes:  changes,
+	}
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, h := range n.hooks {
+		if !h.Wants(jr.Name, events) {
+			continue
+		}
+		wg.Go(func() {
+			if err := h.Send(ctx, p); err != nil {
+				fmt.Fprintf(os.Stderr, "Aviso: falha ao enviar o webhook %s: %v\n", h.Name, err)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func webhookRun(jr JobReport) webhook.Run {
+	return webhook.Run{
+		Job:          jr.Name,
+		Status:       jr.Status,
+		Error:        jr.Error,
+		StartedAt:    jr.StartedAt,
+		DurationMs:   jr.DurationMs,
+		Output:       jr.Output,
+		Rows:         jr.Rows,
+		Pages:        jr.Pages,
+		TotalResults: jr.TotalResults,
+	}
+}
+
+// compareRuns compares a new export with the rows of the previous one.
+func compareRuns(previous string, oldHeader []string, oldRows []map[string]string, output, key string) (*webhook.Changes, error) {
+	newHeader, newRows, err := readExport(output)
+	if err != nil {
+		return nil, err
+	}
+	res, err := diff.Compare(oldHeader, oldRows, newHeader, newRows, diff.Options{Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return webhook.NewChanges(previous, res), nil
+}
+
+// RunWebhookTest sends a sample payload to a URL, or to the webhooks of a
+// manifest, so receivers can be checked before the first real run.
+// Usage: webhook-test [--event success|failure|changes] [--secret s] <url | manifest.toml>
+func RunWebhookTest(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("webhook-test", flag.ContinueOnError)
+	event := fs.String("event", webhook.EventChanges, "evento do exemplo: success, failure ou changes")
+	secret := fs.String("secret", "", "segredo da assinatura HMAC, ao enviar para uma URL")
+	hookName := fs.String("hook", "", "envia só para este webhook do manifesto")
+	retries := fs.Int("retries", 0, "novas tentativas após falha, ao enviar para uma URL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: webhook-test [--event success|failure|changes] [--secret s] <url | manifest.toml>")
+	}
+	if !slices.Contains(webhook.Events, *event) {
+		return fmt.Errorf("unknown event %q (use success, failure or changes)", *event)
+	}
+
+	var hooks []*webhook.Hook
+	target := fs.Arg(0)
+	if _, err := os.Stat(target); err == nil {
+		m, err := config.LoadManifest(target, "")
+		if err != nil {
+			return err
+		}
+		n, err := newNotifier(m, "")
+		if err != nil {
+			return err
+		}
+		for _, h := range n.hooks {
+			if *hookName == "" || h.Name == *hookName {
+				hooks = append(hooks, h)
+			}
+		}
+		if len(hooks) == 0 {
+			return fmt.Errorf("no matching [webhooks.<name>] table in %s", target)
+		}
+	} else {
+		h, err := webhook.ParseHook("test", map[string]string{"url": target, "secret": *secret, "retries": fmt.Sprint(*retries)})
+		if err != nil {
+			return err
+		}
+		hooks = append(hooks, h)
+	}
+
+	p := samplePayload(*event)
+	var failed int
+	for _, h := range hooks {
+		if err := h.Send(ctx, p); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+			continue
+		}
+		signed := "sem assinatura"
+		if h.Secret != "" {
+			signed = "assinado"
+		}
+		fmt.Printf("%s: exemplo %q enviado para %s (%s)\n", h.Name, *event, h.URL, signed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d webhooks failed", failed, len(hooks))
+	}
+	return nil
+}
+
+// samplePayload is a made-up run of the given event.
+func samplePayload(event string) webhook.Payload {
+	started := time.Now().Add(-2 * time.Minute).Truncate(time.Second)
+	p := webhook.Payload{
+		Events: []string{webhook.EventSuccess},
+		Source: "test",
+		Run: webhook.Run{
+			Job:          "exemplo",
+			Status:       JobOK,
+			StartedAt:    started,
+			DurationMs:   (95 * time.Second).Milliseconds(),
+			Output:       "snapshots/" + started.Format(snapshotDateLayout) + "/exemplo/prestadores-sc-guia-de-turismo.csv",
+			Rows:         1523,
+			Pages:        2,
+			TotalResults: 1523,
+		},
+	}
+	switch event {
+	case webhook.EventFailure:
+		p.Events = []string{webhook.EventFailure}
+		p.Run.Status, p.Run.Error = JobFailed, "failed to fetch prestadores: context deadline exceeded (Client.Timeout exceeded while awaiting headers)"
+		p.Run.Output, p.Run.Rows, p.Run.Pages = "", 1000, 1
+	case webhook.EventChanges:
+		p.Events = append(p.Events, webhook.EventChanges)
+		p.Changes = &webhook.Changes{
+			Previous: "snapshots/" + started.AddDate(0, 0, -1).Format(snapshotDateLayout) + "/exemplo/prestadores-sc-guia-de-turismo.csv",
+			Key:      "id",
+			Summary:  diff.Summary{Old: 1521, New: 1523, Added: 3, Removed: 1, Changed: 7, Unchanged: 1513},
+			Added: []webhook.Provider{
+				{Key: "900001", Name: "EXEMPLO GUIA UM", Municipio: "Florianópolis"},
+				{Key: "900002", Name: "EXEMPLO GUIA DOIS", Municipio: "Joinville"},
+				{Key: "900003", Name: "EXEMPLO GUIA TRÊS", Municipio: "Blumenau"},
+			},
+			Removed: []webhook.Provider{
+				{Key: "800001", Name: "EXEMPLO GUIA REMOVIDO", Municipio: "Itajaí"},
+			},
+		}
+	}
+	return p
+}
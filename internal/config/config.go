@@ -0,0 +1,138 @@
+// Package config reads the cadastur-csv config file and CADASTUR_*
+// environment variables. Settings are named after the command-line flags
+// they provide defaults for ("extra-columns", "page-size"...).
+// Only the settings in EnvSettings are read from the environment.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvPrefix starts the environment variable of every setting:
+// "page-size" is read from CADASTUR_PAGE_SIZE.
+const EnvPrefix = "CADASTUR_"
+
+// EnvSettings are the settings that can be given by environment variable:
+// config and profile pick the file and profile, privacy-salt keeps the LGPD
+// secret out of the shell history, the filters and their suggested defaults
+// and the output name pick what a scheduled run exports, and the rest tune
+// the client. Other settings (format, columns...) are not read from the
+// environment, so a stray variable cannot reshape every export.
+var EnvSettings = []string{
+	"config", "profile", "privacy-salt",
+	"uf", "activity", "default-uf", "default-activity", "output", "output-template",
+	"page-size", "timeout", "cache-ttl",
+}
+
+// File is a parsed config file:
+//
+//	[defaults]
+//	uf = 24
+//	format = "ndjson"
+//
+//	[profiles.sc-guias]
+//	uf = 24
+//	activity = 29
+//	extra-columns = ["telefone", "cep"]
+type File struct {
+	// Path is where the file was read from ("" when there was none).
+	Path string
+	// Defaults applies to every run.
+	Defaults map[string]string
+	// Profiles are named bundles of settings selected with --profile.
+	Profiles map[string]map[string]string
+}
+
+// DefaultPath returns the config file in the user config dir:
+// $XDG_CONFIG_HOME/cadastur-csv/config.toml (~/.config/...) on Linux,
+// %AppData%\cadastur-csv\config.toml on Windows.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cadastur-csv", "config.toml"), nil
+}
+
+// Load reads the config file at path. An empty path means DefaultPath,
+// which may be missing (an empty File is returned); an explicit path must exist.
+func Load(path string) (*File, error) {
+	explicit := path != ""
+	if !explicit {
+		p, err := DefaultPath()
+		if err != nil {
+			return &File{}, nil
+		}
+		path = p
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config: %w", err)
+	}
+	defer f.Close()
+
+	tables, _, err := parseTOML(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	file := &File{Path: path, Defaults: map[string]string{}, Profiles: map[string]map[string]string{}}
+	for name, values := range tables {
+		switch {
+		case name == "":
+			if len(values) > 0 {
+				return nil, fmt.Errorf("invalid config %s: settings must be under [defaults] or [profiles.<name>]", path)
+			}
+		case name == "defaults":
+			file.Defaults = values
+		case name == "profiles":
+		case strings.HasPrefix(name, "profiles."):
+			file.Profiles[strings.TrimPrefix(name, "profiles.")] = values
+		default:
+			return nil, fmt.Errorf("invalid config %s: unknown table [%s]", path, name)
+		}
+	}
+	return file, nil
+}
+
+// Profile returns the settings of a named profile.
+func (f *File) Profile(name string) (map[string]string, error) {
+	p, ok := f.Profiles[name]
+	if !ok {
+		where := "no config file"
+		if f.Path != "" {
+			where = f.Path
+		}
+		available := f.ProfileNames()
+		if len(available) == 0 {
+			return nil, fmt.Errorf("unknown profile %q (%s has no profiles)", name, where)
+		}
+		return nil, fmt.Errorf("unknown profile %q in %s (available: %s)", name, where, strings.Join(available, ", "))
+	}
+	return p, nil
+}
+
+// ProfileNames lists the profiles in a stable order.
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for n := range f.Profiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnvName returns the environment variable for a setting: "extra-columns" -> CADASTUR_EXTRA_COLUMNS.
+func EnvName(setting string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(setting, "-", "_"))
+}
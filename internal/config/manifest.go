@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Manifest is a job list for the batch and daemon commands. The runner
+// table ([batch] or [daemon]) configures the command itself:
+//
+//	[batch]
+//	continue-on-error = true
+//	report = "relatorio.json"
+//
+//	[jobs.sc-guias]
+//	uf = 24
+//	activity = 29
+//	output = "exports/sc-guias.csv.gz"
+//
+//	[jobs.rs-municipios]
+//	profile = "sul-agencias"
+//	split-by = "municipio"
+//
+//	[webhooks.equipe]
+//	url = "https://example.com/cadastur"
+//	events = ["failure", "changes"]
+//
+// Job settings are fetch flags, as in config profiles; a job may name a
+// profile and override some of its settings. Webhook settings are read by
+// webhook.ParseHook.
+type Manifest struct {
+	Path string
+	// Runner holds the settings of the runner table.
+	Runner map[string]string
+	// Jobs are run in file order.
+	Jobs []Job
+	// Webhooks are notified of finished runs.
+	Webhooks []Webhook
+}
+
+// Job is one export of a Manifest.
+type Job struct {
+	Name     string
+	Settings map[string]string
+}
+
+// Webhook is one [webhooks.<name>] table of a Manifest.
+type Webhook struct {
+	Name     string
+	Settings map[string]string
+}
+
+// LoadManifest reads a manifest whose runner table is named runner
+// ("batch" or "daemon"); an empty runner accepts either table.
+func LoadManifest(path, runner string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer f.Close()
+
+	tables, order, err := parseTOML(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	if len(tables[""]) > 0 {
+		return nil, fmt.Errorf("invalid manifest %s: settings must be under [%s] or [jobs.<name>]", path, runner)
+	}
+
+	m := &Manifest{Path: path, Runner: map[string]string{}}
+	for _, name := range order {
+		switch {
+		case name == runner, runner == "" && (name == "batch" || name == "daemon"):
+			m.Runner = tables[name]
+		case name == "jobs", name == "webhooks":
+		case strings.HasPrefix(name, "jobs."):
+			m.Jobs = append(m.Jobs, Job{Name: strings.TrimPrefix(name, "jobs."), Settings: tables[name]})
+		case strings.HasPrefix(name, "webhooks."):
+			m.Webhooks = append(m.Webhooks, Webhook{Name: strings.TrimPrefix(name, "webhooks."), Settings: tables[name]})
+		default:
+			return nil, fmt.Errorf("invalid manifest %s: unknown table [%s]", path, name)
+		}
+	}
+	if len(m.Jobs) == 0 {
+		return nil, fmt.Errorf("invalid manifest %s: no [jobs.<name>] tables", path)
+	}
+	return m, nil
+}
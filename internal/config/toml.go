@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// parseTOML reads the subset of TOML used by the config file: [table] and
+// [table.sub] headers, and key = value pairs where value is a string
+// ("..." or '...'), integer, float, boolean or a one-line array of those.
+// Values are returned as strings keyed by table name; arrays are joined with
+// commas, the form the matching command-line flags accept. order lists the
+// table names as they appear in the file.
+func parseTOML(r io.Reader) (tables map[string]map[string]string, order []string, err error) {
+	tables = map[string]map[string]string{"": {}}
+	table := ""
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, nil, fmt.Errorf("line %d: invalid table header %q", n, line)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			parts := strings.Split(table, ".")
+			for i, p := range parts {
+				key, err := parseKey(strings.TrimSpace(p))
+				if err != nil {
+					return nil, nil, fmt.Errorf("line %d: %w", n, err)
+				}
+				parts[i] = key
+			}
+			table = strings.Join(parts, ".")
+			if _, ok := tables[table]; ok {
+				return nil, nil, fmt.Errorf("line %d: table [%s] defined twice", n, table)
+			}
+			tables[table] = map[string]string{}
+			order = append(order, table)
+			continue
+		}
+
+		rawKey, rawValue, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key, err := parseKey(strings.TrimSpace(rawKey))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		value, err := parseValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		if _, dup := tables[table][key]; dup {
+			return nil, nil, fmt.Errorf("line %d: key %q defined twice", n, key)
+		}
+		tables[table][key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return tables, order, nil
+}
+
+// stripComment removes a trailing # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseKey accepts bare keys (letters, digits, - and _) and quoted keys.
+func parseKey(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		return parseString(s)
+	}
+	if s == "" {
+		return "", fmt.Errorf("empty key")
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", fmt.Errorf("invalid key %q", s)
+		}
+	}
+	return s, nil
+}
+
+func parseValue(s string) (string, error) {
+	switch {
+	case s == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return "", fmt.Errorf("arrays must be on one line")
+		}
+		items, err := splitArray(s[1 : len(s)-1])
+		if err != nil {
+			return "", err
+		}
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			v, err := parseValue(item)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, v)
+		}
+		return strings.Join(values, ","), nil
+	case strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'"):
+		return parseString(s)
+	case s == "true" || s == "false":
+		return s, nil
+	}
+	clean := strings.ReplaceAll(s, "_", "")
+	if _, err := strconv.ParseInt(clean, 10, 64); err == nil {
+		return clean, nil
+	}
+	if _, err := strconv.ParseFloat(clean, 64); err == nil {
+		return clean, nil
+	}
+	return "", fmt.Errorf("invalid value %q (quote strings)", s)
+}
+
+// parseString unquotes a basic ("...", with escapes) or literal ('...') string.
+func parseString(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1], nil
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil || s[0] != '"' {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	return v, nil
+}
+
+// splitArray splits the inside of an array on commas outside strings.
+func splitArray(s string) ([]string, error) {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string in array")
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		items = append(items, last)
+	}
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("empty array item")
+		}
+	}
+	return items, nil
+}
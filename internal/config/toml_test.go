@@ -0,0 +1,114 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTOMLValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"basic string", `v = "sc guias"`, "sc guias"},
+		{"escapes", `v = "a\"b\\c\td"`, "a\"b\\c\td"},
+		{"literal string", `v = 'C:\dados\saida.csv'`, `C:\dados\saida.csv`},
+		{"hash inside basic string", `v = "a#b" # comment`, "a#b"},
+		{"hash inside literal string", `v = 'a#b'#comment`, "a#b"},
+		{"escaped quote before hash", `v = "a\"#b"`, `a"#b`},
+		{"integer", `v = 24`, "24"},
+		{"negative integer", `v = -3`, "-3"},
+		{"underscores", `v = 1_000`, "1000"},
+		{"float", `v = 1.5`, "1.5"},
+		{"true", `v = true`, "true"},
+		{"false", `v = false`, "false"},
+		{"array", `v = ["telefone", "cep"]`, "telefone,cep"},
+		{"array of literals", `v = ['a', 'b']`, "a,b"},
+		{"array with comma in string", `v = ["a,b", "c"]`, "a,b,c"},
+		{"array of numbers", `v = [1, 2, 3]`, "1,2,3"},
+		{"array trailing comma", `v = ["a", "b",]`, "a,b"},
+		{"empty array", `v = []`, ""},
+		{"quoted key", `"v" = 1`, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, _, err := parseTOML(strings.NewReader("[t]\n" + tt.input + "\n"))
+			if err != nil {
+				t.Fatalf("parseTOML(%q): %v", tt.input, err)
+			}
+			if got := tables["t"]["v"]; got != tt.want {
+				t.Errorf("parseTOML(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLTables(t *testing.T) {
+	input := `# config
+top = 1
+
+[defaults]   # every run
+timeout = "60s"
+
+[ profiles . sc-guias ]
+uf = 24
+
+[profiles."rj guias"]
+uf = 19
+`
+	tables, order, err := parseTOML(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantOrder := []string{"defaults", "profiles.sc-guias", "profiles.rj guias"}
+	if !slices.Equal(order, wantOrder) {
+		t.Errorf("order = %q, want %q", order, wantOrder)
+	}
+	want := map[string]map[string]string{
+		"":                  {"top": "1"},
+		"defaults":          {"timeout": "60s"},
+		"profiles.sc-guias": {"uf": "24"},
+		"profiles.rj guias": {"uf": "19"},
+	}
+	if !maps.EqualFunc(tables, want, maps.Equal) {
+		t.Errorf("tables = %v, want %v", tables, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"duplicate key", "[t]\na = 1\na = 2", `line 3: key "a" defined twice`},
+		{"duplicate table", "[t]\na = 1\n[t]\nb = 2", "line 3: table [t] defined twice"},
+		{"duplicate empty table", "[jobs.x]\n[jobs.x]", "line 2: table [jobs.x] defined twice"},
+		{"duplicate table after keys", "[jobs.x]\n[jobs.y]\nuf = 1\n[jobs.x]", "line 4: table [jobs.x] defined twice"},
+		{"duplicate quoted table", "[jobs.x]\n[jobs.\"x\"]", "line 2: table [jobs.x] defined twice"},
+		{"array of tables", "[[jobs]]", "invalid table header"},
+		{"unclosed header", "[jobs", "invalid table header"},
+		{"missing equals", "[t]\nvalue", "line 2: expected key = value"},
+		{"missing value", "a =", "missing value"},
+		{"bare string", "a = sc", "quote strings"},
+		{"unterminated string", `a = "sc`, "invalid string"},
+		{"single quote inside basic", `a = "sc'`, "invalid string"},
+		{"multi-line array", "a = [\n1]", "arrays must be on one line"},
+		{"unterminated string in array", `a = ["a, "b"]`, "unterminated string in array"},
+		{"empty array item", `a = [1,,2]`, "empty array item"},
+		{"invalid key", "a b = 1", "invalid key"},
+		{"empty key", "= 1", "empty key"},
+		{"empty table name part", "[profiles.]", "empty key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseTOML(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseTOML(%q) error = %v, want %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
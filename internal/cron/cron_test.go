@@ -0,0 +1,108 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Wednesday, 14 Oct 2026.
+	from := time.Date(2026, 10, 14, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want string // "" means never
+	}{
+		{"* * * * *", "2026-10-14 10:08"},
+		{"*/15 * * * *", "2026-10-14 10:15"},
+		{"5/10 * * * *", "2026-10-14 10:15"},
+		{"0,30 * * * *", "2026-10-14 10:30"},
+		{"0 8-18/2 * * *", "2026-10-14 12:00"},
+		{"30 9 * * mon-fri", "2026-10-15 09:30"},
+		{"30 9 * * MON-FRI", "2026-10-15 09:30"},
+		{"0 0 * * 0", "2026-10-18 00:00"},
+		{"0 0 * * 7", "2026-10-18 00:00"},
+		{"0 0 * * sat-7", "2026-10-17 00:00"},
+		{"0 0 31 * *", "2026-10-31 00:00"},
+		{"0 0 * jan,jul *", "2027-01-01 00:00"},
+		{"0 0 1 * mon", "2026-10-19 00:00"},
+		{"0 0 13 * fri", "2026-10-16 00:00"},
+		{"0 0 */2 * mon", "2026-10-19 00:00"},
+		{"0 0 1 * */2", "2026-11-01 00:00"},
+		{"0 0 29 2 *", "2028-02-29 00:00"},
+		{"0 0 30 2 *", ""},
+		{"0 0 31 4 *", ""},
+		{"@hourly", "2026-10-14 11:00"},
+		{"@daily", "2026-10-15 00:00"},
+		{"@weekly", "2026-10-18 00:00"},
+		{"@monthly", "2026-11-01 00:00"},
+		{"@yearly", "2027-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		got := ""
+		if next := s.Next(from); !next.IsZero() {
+			got = next.Format("2006-01-02 15:04")
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q).Next = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestNextIsAfter(t *testing.T) {
+	s, err := Parse("*/15 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 10, 14, 10, 15, 0, 0, time.UTC)
+	if got, want := s.Next(from), from.Add(15*time.Minute); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", from, got, want)
+	}
+}
+
+func TestNextLocation(t *testing.T) {
+	loc := time.FixedZone("BRT", -3*60*60)
+	s, err := Parse("0 6 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 10, 14, 7, 0, 0, 0, loc)
+	want := time.Date(2026, 10, 15, 6, 0, 0, 0, loc)
+	if got := s.Next(from); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Next(%v) = %v, want %v", from, got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * *", "want 5 fields"},
+		{"* * * * * *", "want 5 fields"},
+		{"@every 5m", "want 5 fields"},
+		{"60 * * * *", "minute: invalid value"},
+		{"* 24 * * *", "hour: invalid value"},
+		{"* * 0 * *", "day of month: invalid value"},
+		{"* * 32 * *", "day of month: invalid value"},
+		{"* * * 13 *", "month: invalid value"},
+		{"* * * foo *", "month: invalid value"},
+		{"* * * * 8", "day of week: invalid value"},
+		{"*/0 * * * *", "minute: invalid step"},
+		{"*/x * * * *", "minute: invalid step"},
+		{"5-1 * * * *", "minute: invalid range"},
+		{"a * * * *", "minute: invalid value"},
+		{"1,,2 * * * *", "minute: invalid value"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
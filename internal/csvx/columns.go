@@ -0,0 +1,182 @@
+package csvx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/normalize"
+)
+
+// Column describes one CSV column: its header name and how the cell value
+// is rendered from a Prestador.
+type Column struct {
+	Name  string
+	Value func(p cadastur.Prestador) string
+}
+
+// DefaultKey is the column that identifies a provider when none is chosen,
+// shared by every command that matches rows across exports (diff, expiring).
+const DefaultKey = "id"
+
+// DefaultColumns lists every column of the export, in header order.
+// Normalizes telephone and CEP to digits only, handles dates, bools, and pointers.
+var DefaultColumns = []Column{
+	{"id", func(p cadastur.Prestador) string { return fmt.Sprint(p.ID) }},
+	{"tipoPessoa", func(p cadastur.Prestador) string { return string(p.TipoPessoa) }},
+	{"numeroCadastro", func(p cadastur.Prestador) string { return string(p.NumeroCadastro) }},
+	dateColumn("inicioVigencia", normalize.DateISO),
+	dateColumn("fimVigencia", normalize.DateISO),
+	{"website", func(p cadastur.Prestador) string { return string(p.NoWebSite) }},
+	{"telefone", func(p cadastur.Prestador) string { return normalize.OnlyDigits(string(p.NuTelefone)) }},
+	textColumn("logradouro"),
+	textColumn("complemento"),
+	{"cep", func(p cadastur.Prestador) string { return normalize.OnlyDigits(string(p.NuCep)) }},
+	{"uf", func(p cadastur.Prestador) string { return string(p.Sguf) }},
+	textColumn("bairro"),
+	textColumn("nomePrestador"),
+	{"registroRf", func(p cadastur.Prestador) string { return string(p.RegistroRf) }},
+	{"nuAtividadeTuristica", func(p cadastur.Prestador) string { return fmt.Sprint(p.NuAtividadeTuristica) }},
+	textColumn("atividade"),
+	{"nuSituacaoCadastral", func(p cadastur.Prestador) string { return fmt.Sprint(p.NuSituacaoCadastral) }},
+	textColumn("situacao"),
+	{"nuUf", func(p cadastur.Prestador) string { return fmt.Sprint(p.NuUf) }},
+	{"localidadeNuUf", func(p cadastur.Prestador) string { return normalize.IntPtrToStr(p.LocalidadeNuUf) }},
+	textColumn("municipio"),
+	textColumn("localidade"),
+	textColumn("noLocalidade"),
+	{"nuLocalidade", func(p cadastur.Prestador) string { return fmt.Sprint(p.NuLocalidade) }},
+	{"nuMunicipio", func(p cadastur.Prestador) string { return fmt.Sprint(p.NuMunicipio) }},
+	{"nuPessoa", func(p cadastur.Prestador) string { return fmt.Sprint(p.NuPessoa) }},
+	{"possuiVeiculo", func(p cadastur.Prestador) string { return normalize.BoolToStr(bool(p.FlPossuiVeiculo)) }},
+	{"nuSitCadTramite", func(p cadastur.Prestador) string { return fmt.Sprint(p.NuSitCadTramite) }},
+	textColumn("atividadeRedeSociais"),
+}
+
+// textFields maps the free-text columns to their raw values. These columns
+// go through normalize.FixMojibake.
+var textFields = map[string]func(p cadastur.Prestador) string{
+	"logradouro":           func(p cadastur.Prestador) string { return string(p.NoLogradouro) },
+	"complemento":          func(p cadastur.Prestador) string { return string(p.Complemento) },
+	"bairro":               func(p cadastur.Prestador) string { return string(p.NoBairro) },
+	"nomePrestador":        func(p cadastur.Prestador) string { return string(p.NomePrestador) },
+	"atividade":            func(p cadastur.Prestador) string { return string(p.Atividade) },
+	"situacao":             func(p cadastur.Prestador) string { return string(p.Situacao) },
+	"municipio":            func(p cadastur.Prestador) string { return string(p.Municipio) },
+	"localidade":           func(p cadastur.Prestador) string { return string(p.Localidade) },
+	"noLocalidade":         func(p cadastur.Prestador) string { return string(p.NoLocalidade) },
+	"atividadeRedeSociais": func(p cadastur.Prestador) string { return string(p.AtividadeRedeSociais) },
+}
+
+// textColumn renders one of textFields with mojibake repaired.
+func textColumn(name string) Column {
+	raw := textFields[name]
+	return Column{name, func(p cadastur.Prestador) string { return normalize.FixMojibake(raw(p)) }}
+}
+
+// CountRepairs calls repaired with the column name of every free-text field
+// of p whose mojibake was repaired, so a run can report what it fixed.
+func CountRepairs(p cadastur.Prestador, repaired func(column string)) {
+	for _, c := range DefaultColumns {
+		raw, ok := textFields[c.Name]
+		if !ok {
+			continue
+		}
+		if _, changed := normalize.RepairMojibake(raw(p)); changed {
+			repaired(c.Name)
+		}
+	}
+}
+
+// dateFields maps the vigência date columns to their millisecond timestamps.
+var dateFields = map[string]func(p cadastur.Prestador) int64{
+	"inicioVigencia": func(p cadastur.Prestador) int64 { return int64(p.DtInicioVigencia) },
+	"fimVigencia":    func(p cadastur.Prestador) int64 { return int64(p.DtFimVigencia) },
+}
+
+// dateColumn renders one of dateFields in the given format.
+func dateColumn(name string, f normalize.DateFormat) Column {
+	ms := dateFields[name]
+	return Column{name, func(p cadastur.Prestador) string { return normalize.FormatMs(ms(p), f) }}
+}
+
+// WithDateFormat returns a copy of columns whose vigência dates are rendered in f.
+func WithDateFormat(columns []Column, f normalize.DateFormat) []Column {
+	out := append([]Column(nil), columns...)
+	for i, c := range out {
+		if _, ok := dateFields[c.Name]; ok {
+			out[i] = dateColumn(c.Name, f)
+		}
+	}
+	return out
+}
+
+// ExtraColumns are optional column groups, appended after DefaultColumns
+// when selected by name (see ColumnsWith).
+var ExtraColumns = map[string][]Column{
+	"telefone":  phoneColumns,
+	"cep":       cepColumns,
+	"website":   websiteColumns,
+	"redes":     socialColumns(),
+	"documento": documentColumns,
+}
+
+// ExtraGroups lists the names of ExtraColumns in a stable order.
+func ExtraGroups() []string {
+	groups := make([]string, 0, len(ExtraColumns))
+	for g := range ExtraColumns {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// ColumnsWith returns DefaultColumns followed by the given extra column groups.
+func ColumnsWith(groups []string) ([]Column, error) {
+	columns := append([]Column(nil), DefaultColumns...)
+	for _, g := range groups {
+		extra, ok := ExtraColumns[g]
+		if !ok {
+			return nil, fmt.Errorf("unknown column group %q (available: %s)", g, strings.Join(ExtraGroups(), ", "))
+		}
+		columns = append(columns, extra...)
+	}
+	return columns, nil
+}
+
+// ColumnByName returns the default or extra column with the given header name.
+func ColumnByName(name string) (Column, bool) {
+	for _, c := range DefaultColumns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	for _, group := range ExtraColumns {
+		for _, c := range group {
+			if c.Name == name {
+				return c, true
+			}
+		}
+	}
+	return Column{}, false
+}
+
+// ColumnNames returns the header names of the given columns.
+func ColumnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// NamedColumns builds value-less columns from header names, for writers that
+// only receive already-rendered records (see RecordWriter.WriteRecord).
+func NamedColumns(names []string) []Column {
+	columns := make([]Column, len(names))
+	for i, name := range names {
+		columns[i] = Column{Name: name}
+	}
+	return columns
+}
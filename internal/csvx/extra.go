@@ -0,0 +1,146 @@
+package csvx
+
+import (
+	"sync"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/normalize"
+)
+
+// phoneColumns ("telefone" group) split NuTelefone into up to two E.164
+// numbers with their kind, next to the raw digits of the "telefone" column.
+var phoneColumns = []Column{
+	{"telefoneE164", func(p cadastur.Prestador) string { return validPhone(p, 0).E164 }},
+	{"telefoneTipo", func(p cadastur.Prestador) string { return validPhone(p, 0).Kind }},
+	{"telefone2E164", func(p cadastur.Prestador) string { return validPhone(p, 1).E164 }},
+	{"telefone2Tipo", func(p cadastur.Prestador) string { return validPhone(p, 1).Kind }},
+	{"telefoneValido", func(p cadastur.Prestador) string {
+		phones := parsePhones(p)
+		ok := len(phones) > 0
+		for _, ph := range phones {
+			ok = ok && ph.Valid
+		}
+		return normalize.BoolToStr(ok)
+	}},
+}
+
+// cepColumns ("cep" group) format NuCep as NNNNN-NNN and check it against the provider's UF.
+var cepColumns = []Column{
+	{"cepFormatado", func(p cadastur.Prestador) string { return normalize.FormatCEP(string(p.NuCep)) }},
+	{"cepValidacao", func(p cadastur.Prestador) string { return normalize.CheckCEP(string(p.NuCep), string(p.Sguf)) }},
+}
+
+// websiteColumns ("website" group) normalize NoWebSite. When the value is
+// really a social-network profile, websiteNormalizado stays empty, the
+// network is named in websiteRedeSocial and the "redes" group carries the profile.
+var websiteColumns = []Column{
+	{"websiteNormalizado", func(p cadastur.Prestador) string {
+		w := normalize.NormalizeWebsite(string(p.NoWebSite))
+		if w.Network != "" {
+			return ""
+		}
+		return w.URL
+	}},
+	{"websiteValido", func(p cadastur.Prestador) string {
+		return normalize.BoolToStr(normalize.NormalizeWebsite(string(p.NoWebSite)).Valid)
+	}},
+	{"websiteRedeSocial", func(p cadastur.Prestador) string {
+		return normalize.NormalizeWebsite(string(p.NoWebSite)).Network
+	}},
+}
+
+// socialColumns ("redes" group) have one column per social network, parsed
+// from AtividadeRedeSociais. A website that is really a social profile fills
+// its network's column when the social field has none.
+func socialColumns() []Column {
+	columns := make([]Column, 0, len(normalize.SocialNetworks))
+	for _, network := range normalize.SocialNetworks {
+		columns = append(columns, Column{network, func(p cadastur.Prestador) string {
+			return socialProfiles(p)[network]
+		}})
+	}
+	return columns
+}
+
+// socialProfiles merges the profiles found in the social field and in the
+// website. The columns of the group share one parse per row.
+func socialProfiles(p cadastur.Prestador) normalize.SocialProfiles {
+	social, website, uf := string(p.AtividadeRedeSociais), string(p.NoWebSite), string(p.Sguf)
+	return socialMemo.get(social+"\x00"+website+"\x00"+uf, func() normalize.SocialProfiles {
+		profiles := normalize.ParseSocial(social, uf)
+		if network, profile := normalize.SocialProfileURL(website); profile != "" {
+			if _, ok := profiles[network]; !ok {
+				profiles[network] = profile
+			}
+		}
+		return profiles
+	})
+}
+
+// parsePhones parses NuTelefone once per row for the "telefone" group.
+func parsePhones(p cadastur.Prestador) []normalize.Phone {
+	raw, uf := string(p.NuTelefone), string(p.Sguf)
+	return phoneMemo.get(raw+"\x00"+uf, func() []normalize.Phone {
+		return normalize.ParsePhones(raw, uf)
+	})
+}
+
+var (
+	socialMemo rowMemo[normalize.SocialProfiles]
+	phoneMemo  rowMemo[[]normalize.Phone]
+)
+
+// rowMemo keeps the last result of a per-row parse keyed by its inputs, so
+// the columns of a group derived from the same field parse it once per row.
+// Results are shared and must not be modified. Concurrent exports (serve)
+// only evict each other's entry.
+type rowMemo[T any] struct {
+	mu  sync.Mutex
+	key string
+	val T
+	ok  bool
+}
+
+func (m *rowMemo[T]) get(key string, parse func() T) T {
+	m.mu.Lock()
+	if m.ok && m.key == key {
+		v := m.val
+		m.mu.Unlock()
+		return v
+	}
+	m.mu.Unlock()
+	v := parse()
+	m.mu.Lock()
+	m.key, m.val, m.ok = key, v, true
+	m.mu.Unlock()
+	return v
+}
+
+// documentColumns ("documento" group) classify the provider's registration
+// document as CPF or CNPJ, format it with the standard mask and validate its
+// check digits (see normalize.ProviderDocument).
+var documentColumns = []Column{
+	{"documentoTipo", func(p cadastur.Prestador) string { return document(p).Kind }},
+	{"documentoFormatado", func(p cadastur.Prestador) string { return document(p).Formatted }},
+	{"documentoValido", func(p cadastur.Prestador) string { return normalize.BoolToStr(document(p).Valid) }},
+	{"documentoValidacao", func(p cadastur.Prestador) string { return document(p).Status }},
+}
+
+// document parses the provider's CPF/CNPJ (see normalize.ProviderDocument).
+func document(p cadastur.Prestador) normalize.Document {
+	return normalize.ProviderDocument(string(p.RegistroRf), string(p.TipoPessoa))
+}
+
+// validPhone returns the i-th valid number of p, or a zero Phone.
+func validPhone(p cadastur.Prestador, i int) normalize.Phone {
+	for _, ph := range parsePhones(p) {
+		if !ph.Valid {
+			continue
+		}
+		if i == 0 {
+			return ph
+		}
+		i--
+	}
+	return normalize.Phone{}
+}
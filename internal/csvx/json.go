@@ -0,0 +1,74 @@
+package csvx
+
+import (
+	"bufio"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+// JSONWriter writes a JSON array with one object per provider, keyed like
+// the NDJSON export. The array is opened when the writer is created and
+// closed by Close, so an unclosed stream is visibly incomplete.
+type JSONWriter struct {
+	out     *output
+	buf     *bufio.Writer
+	columns []Column
+	rows    int
+	closed  bool
+}
+
+func newJSONWriter(out *output, columns []Column) (*JSONWriter, error) {
+	w := &JSONWriter{
+		out:     out,
+		buf:     bufio.NewWriter(out),
+		columns: columns,
+	}
+	if err := w.buf.WriteByte('['); err != nil {
+		out.close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteHeader is a no-op: every object carries its own field names.
+func (w *JSONWriter) WriteHeader() error {
+	return nil
+}
+
+// WriteRow writes a Prestador as one array element.
+func (w *JSONWriter) WriteRow(p cadastur.Prestador) error {
+	return w.WriteRecord(renderRow(w.columns, p))
+}
+
+// WriteRecord writes an already-rendered row as one array element.
+func (w *JSONWriter) WriteRecord(values []string) error {
+	if w.rows > 0 {
+		w.buf.WriteByte(',')
+	}
+	w.rows++
+	w.buf.WriteByte('\n')
+	return writeObject(w.buf, w.columns, values)
+}
+
+// Flush flushes buffered elements (and the gzip stream, if any).
+func (w *JSONWriter) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.out.flush()
+}
+
+// Close ends the array and closes the output.
+// Calling Close more than once is a no-op.
+func (w *JSONWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.buf.WriteString("\n]\n")
+	if err := w.buf.Flush(); err != nil {
+		w.out.close()
+		return err
+	}
+	return w.out.close()
+}
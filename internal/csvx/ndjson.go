@@ -0,0 +1,99 @@
+package csvx
+
+import (
+	"bufio"
+	"encoding/json"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+// NDJSONWriter writes one JSON object per line, with the same column names
+// and normalized values as the CSV export, in column order.
+type NDJSONWriter struct {
+	out     *output
+	buf     *bufio.Writer
+	columns []Column
+	closed  bool
+}
+
+// NewNDJSONWriter creates a new NDJSON writer for the specified filename.
+// The file is gzip-compressed when opts.Compress is set or the name ends in ".gz".
+func NewNDJSONWriter(filename string, opts Options) (*NDJSONWriter, error) {
+	out, err := createOutput(filename, opts.Compress, false)
+	if err != nil {
+		return nil, err
+	}
+	return newNDJSONWriter(out, opts.columns()), nil
+}
+
+func newNDJSONWriter(out *output, columns []Column) *NDJSONWriter {
+	return &NDJSONWriter{
+		out:     out,
+		buf:     bufio.NewWriter(out),
+		columns: columns,
+	}
+}
+
+// WriteHeader is a no-op: every NDJSON line carries its own field names.
+func (w *NDJSONWriter) WriteHeader() error {
+	return nil
+}
+
+// WriteRow writes a Prestador as one JSON line.
+func (w *NDJSONWriter) WriteRow(p cadastur.Prestador) error {
+	return w.WriteRecord(renderRow(w.columns, p))
+}
+
+// WriteRecord writes an already-rendered row as one JSON line.
+func (w *NDJSONWriter) WriteRecord(values []string) error {
+	if err := writeObject(w.buf, w.columns, values); err != nil {
+		return err
+	}
+	return w.buf.WriteByte('\n')
+}
+
+// writeObject writes a row as a JSON object, building it by hand to keep
+// the keys in column order.
+func writeObject(buf *bufio.Writer, columns []Column, values []string) error {
+	buf.WriteByte('{')
+	for i, c := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(c.Name)
+		buf.Write(k)
+		buf.WriteByte(':')
+		var v string
+		if i < len(values) {
+			v = values[i]
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return buf.WriteByte('}')
+}
+
+// Flush flushes buffered lines (and the gzip stream, if any).
+func (w *NDJSONWriter) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.out.flush()
+}
+
+// Close flushes any buffered lines and closes the underlying file.
+// Calling Close more than once is a no-op.
+func (w *NDJSONWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.buf.Flush(); err != nil {
+		w.out.close()
+		return err
+	}
+	return w.out.close()
+}
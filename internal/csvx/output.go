@@ -0,0 +1,183 @@
+package csvx
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format identifies an export encoding.
+type Format string
+
+const (
+	// FormatCSV is comma-separated values with a UTF-8 BOM.
+	FormatCSV Format = "csv"
+	// FormatNDJSON is newline-delimited JSON, one object per provider.
+	FormatNDJSON Format = "ndjson"
+	// FormatJSON is a JSON array of objects. It is only offered for streams
+	// (see NewStream): an array cannot be appended to or read back row by row.
+	FormatJSON Format = "json"
+)
+
+// ParseFormat validates a user-supplied format name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatCSV, FormatNDJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (use csv or ndjson)", s)
+}
+
+// FormatFromPath infers the format from a file extension, ignoring a trailing ".gz".
+// Anything other than .ndjson/.jsonl is treated as CSV.
+func FormatFromPath(path string) Format {
+	switch filepath.Ext(strings.TrimSuffix(strings.ToLower(path), ".gz")) {
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	return FormatCSV
+}
+
+// IsCompressedPath reports whether path names a gzip file.
+func IsCompressedPath(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".gz")
+}
+
+// Extension returns the file extension for the given options, e.g. ".csv" or ".ndjson.gz".
+func Extension(opts Options) string {
+	ext := "." + string(opts.format(""))
+	if opts.Compress {
+		ext += ".gz"
+	}
+	return ext
+}
+
+// Options configures how an export file is encoded.
+type Options struct {
+	// Format selects CSV or NDJSON. Empty means inferred from the file name.
+	Format Format
+	// Compress streams the output through gzip. It is implied by a ".gz" file name.
+	Compress bool
+	// Columns lists the exported columns. Nil means DefaultColumns.
+	Columns []Column
+}
+
+func (o Options) format(path string) Format {
+	if o.Format != "" {
+		return o.Format
+	}
+	if path == "" {
+		return FormatCSV
+	}
+	return FormatFromPath(path)
+}
+
+func (o Options) columns() []Column {
+	if o.Columns != nil {
+		return o.Columns
+	}
+	return DefaultColumns
+}
+
+// output is the byte stream a writer encodes into: a file or a stream,
+// optionally gzip-compressed.
+type output struct {
+	file io.WriteCloser
+	gz   *gzip.Writer
+	w    io.Writer
+}
+
+// createOutput creates (or, with appendMode, reopens) path for writing.
+// Appending to a gzip file starts a new gzip member, which readers
+// decompress as one continuous stream.
+func createOutput(path string, compress, appendMode bool) (*output, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOutput(f, compress || IsCompressedPath(path)), nil
+}
+
+func newOutput(dst io.WriteCloser, compress bool) *output {
+	out := &output{file: dst, w: dst}
+	if compress {
+		out.gz = gzip.NewWriter(dst)
+		out.w = out.gz
+	}
+	return out
+}
+
+// nopCloser keeps a stream open when its writer is closed.
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func (o *output) Write(p []byte) (int, error) {
+	return o.w.Write(p)
+}
+
+// flush pushes compressed data to the file so readers see complete pages.
+func (o *output) flush() error {
+	if o.gz != nil {
+		return o.gz.Flush()
+	}
+	return nil
+}
+
+func (o *output) close() error {
+	if o.gz != nil {
+		if err := o.gz.Close(); err != nil {
+			o.file.Close()
+			return err
+		}
+	}
+	return o.file.Close()
+}
+
+// Create opens an export file for writing, choosing the CSV or NDJSON
+// writer from opts and the file name.
+func Create(path string, opts Options) (RecordWriter, error) {
+	if opts.format(path) == FormatNDJSON {
+		return NewNDJSONWriter(path, opts)
+	}
+	return NewWriter(path, opts)
+}
+
+// NewStream writes an export to w, e.g. an HTTP response, instead of a file.
+// The format defaults to CSV, which starts with a UTF-8 BOM as in files;
+// FormatJSON is accepted too. Closing the writer finishes the encoding
+// (and the gzip stream) but does not close w.
+func NewStream(w io.Writer, opts Options) (RecordWriter, error) {
+	out := newOutput(nopCloser{w}, opts.Compress)
+	switch opts.format("") {
+	case FormatNDJSON:
+		return newNDJSONWriter(out, opts.columns()), nil
+	case FormatJSON:
+		return newJSONWriter(out, opts.columns())
+	}
+	if _, err := out.Write(utf8BOM); err != nil {
+		return nil, err
+	}
+	return newWriter(out, opts.columns()), nil
+}
+
+// openAppend reopens an existing export file to append more rows.
+// No BOM or header is written since the file already has them.
+func openAppend(path string, opts Options) (RecordWriter, error) {
+	out, err := createOutput(path, opts.Compress, true)
+	if err != nil {
+		return nil, err
+	}
+	if opts.format(path) == FormatNDJSON {
+		return newNDJSONWriter(out, opts.columns()), nil
+	}
+	return newWriter(out, opts.columns()), nil
+}
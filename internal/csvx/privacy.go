@@ -0,0 +1,195 @@
+package csvx
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/normalize"
+)
+
+// Privacy actions applied to a column of pessoa física rows.
+const (
+	// PrivacyKeep leaves the value untouched.
+	PrivacyKeep = "keep"
+	// PrivacyRedact empties the value.
+	PrivacyRedact = "redact"
+	// PrivacyMask hides all but the middle characters, as in ***.982.247-**.
+	PrivacyMask = "mask"
+	// PrivacyHash replaces the value with a salted deterministic hash.
+	PrivacyHash = "hash"
+)
+
+// DefaultPrivacyRules is the LGPD profile for pessoa física rows: identifiers
+// are hashed (joins across exports still work with the same salt), documents
+// are masked, and contacts (phones, website and social profiles, whose free
+// text often holds WhatsApp numbers and personal handles) and street
+// addresses are removed, leaving bairro and município. Rules for columns that
+// are not exported are ignored.
+var DefaultPrivacyRules = map[string]string{
+	"id":                   PrivacyHash,
+	"nuPessoa":             PrivacyHash,
+	"numeroCadastro":       PrivacyHash,
+	"registroRf":           PrivacyMask,
+	"documentoFormatado":   PrivacyMask,
+	"telefone":             PrivacyRedact,
+	"telefoneE164":         PrivacyRedact,
+	"telefone2E164":        PrivacyRedact,
+	"logradouro":           PrivacyRedact,
+	"complemento":          PrivacyRedact,
+	"cep":                  PrivacyRedact,
+	"cepFormatado":         PrivacyRedact,
+	"website":              PrivacyRedact,
+	"websiteNormalizado":   PrivacyRedact,
+	"atividadeRedeSociais": PrivacyRedact,
+	"instagram":            PrivacyRedact,
+	"facebook":             PrivacyRedact,
+	"youtube":              PrivacyRedact,
+	"tiktok":               PrivacyRedact,
+	"linkedin":             PrivacyRedact,
+	"whatsapp":             PrivacyRedact,
+}
+
+// Privacy configures the redaction of pessoa física rows.
+type Privacy struct {
+	// Salt keys the hashes; keep it secret and stable to join exports.
+	Salt string
+	// Rules maps a column name to a Privacy* action.
+	Rules map[string]string
+}
+
+// ParsePrivacyRules reads a "column=action,..." spec and merges it over
+// DefaultPrivacyRules, e.g. "nomePrestador=hash,cep=keep".
+func ParsePrivacyRules(spec string) (map[string]string, error) {
+	rules := make(map[string]string, len(DefaultPrivacyRules))
+	for col, action := range DefaultPrivacyRules {
+		rules[col] = action
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		col, action, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid privacy rule %q (use column=action)", item)
+		}
+		col, action = strings.TrimSpace(col), strings.TrimSpace(action)
+		switch action {
+		case PrivacyKeep, PrivacyRedact, PrivacyMask, PrivacyHash:
+		default:
+			return nil, fmt.Errorf("unknown privacy action %q for column %q (use keep, redact, mask or hash)", action, col)
+		}
+		if _, ok := ColumnByName(col); !ok {
+			return nil, fmt.Errorf("unknown column %q in privacy rule", col)
+		}
+		rules[col] = action
+	}
+	return rules, nil
+}
+
+// WithPrivacy returns a copy of columns where, for pessoa física rows, each
+// column with a rule is redacted, masked or hashed. Pessoa jurídica rows are
+// written as is.
+func WithPrivacy(columns []Column, p Privacy) []Column {
+	out := append([]Column(nil), columns...)
+	for i, c := range out {
+		action := p.Rules[c.Name]
+		if action == "" || action == PrivacyKeep {
+			continue
+		}
+		value, name := c.Value, c.Name
+		out[i].Value = func(row cadastur.Prestador) string {
+			v := value(row)
+			if normalize.PessoaKind(string(row.TipoPessoa)) != normalize.CPF {
+				return v
+			}
+			return p.apply(action, name, v)
+		}
+	}
+	return out
+}
+
+// FindColumn returns the column called name from columns, the exported
+// columns with their wrappers applied. A column that is not exported is
+// taken from the known columns, with the privacy rules applied when privacy
+// is not nil, so derived values (file names, samples) never reveal more
+// than the export.
+func FindColumn(columns []Column, privacy *Privacy, name string) (Column, bool) {
+	for _, c := range columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	c, ok := ColumnByName(name)
+	if ok && privacy != nil {
+		c = WithPrivacy([]Column{c}, *privacy)[0]
+	}
+	return c, ok
+}
+
+func (p Privacy) apply(action, column, v string) string {
+	if strings.TrimSpace(v) == "" {
+		return v
+	}
+	switch action {
+	case PrivacyRedact:
+		return ""
+	case PrivacyMask:
+		return maskMiddle(v)
+	case PrivacyHash:
+		// The column name is part of the message so equal values in
+		// different columns (id and nuPessoa) do not share a hash.
+		mac := hmac.New(sha256.New, []byte(p.Salt))
+		mac.Write([]byte(column + "\x00" + v))
+		return hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	return v
+}
+
+// maskMiddle replaces letters and digits with '*', keeping from the 4th to the
+// third-to-last (the public CPF convention: 529.982.247-25 -> ***.982.247-**).
+// Punctuation is kept so the mask still reads as a document.
+func maskMiddle(v string) string {
+	n := 0
+	for _, r := range v {
+		if isAlnum(r) {
+			n++
+		}
+	}
+	var b strings.Builder
+	i := 0
+	for _, r := range v {
+		if !isAlnum(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if i >= 3 && i < n-2 {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('*')
+		}
+		i++
+	}
+	return b.String()
+}
+
+func isAlnum(r rune) bool {
+	return r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z'
+}
+
+// PrivacyColumns lists the columns with a non-keep rule, sorted, for the run summary.
+func PrivacyColumns(rules map[string]string, columns []Column) []string {
+	var names []string
+	for _, c := range columns {
+		if a := rules[c.Name]; a != "" && a != PrivacyKeep {
+			names = append(names, c.Name+"="+a)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
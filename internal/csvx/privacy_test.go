@@ -0,0 +1,231 @@
+package csvx
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+var (
+	pessoaFisica = cadastur.Prestador{
+		ID:            101,
+		TipoPessoa:    "PF",
+		NomePrestador: "MARIA DA SILVA",
+		RegistroRf:    "529.982.247-25",
+		NuTelefone:    "(48) 99999-8888",
+		NoLogradouro:  "Rua das Flores, 10",
+		NoBairro:      "Centro",
+		Municipio:     "Florianópolis",
+	}
+	pessoaJuridica = cadastur.Prestador{
+		ID:            202,
+		TipoPessoa:    "PJ",
+		NomePrestador: "AGENCIA EXEMPLO LTDA",
+		RegistroRf:    "11.222.333/0001-81",
+		NuTelefone:    "(48) 3333-4444",
+		NoLogradouro:  "Av. Beira-Mar, 200",
+		NoBairro:      "Centro",
+		Municipio:     "Florianópolis",
+	}
+)
+
+// rowValues renders p with columns as a column name -> value map.
+func rowValues(columns []Column, p cadastur.Prestador) map[string]string {
+	values := map[string]string{}
+	for _, c := range columns {
+		values[c.Name] = c.Value(p)
+	}
+	return values
+}
+
+func TestWithPrivacy(t *testing.T) {
+	rules, err := ParsePrivacyRules("nomePrestador=hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := WithPrivacy(DefaultColumns, Privacy{Salt: "segredo", Rules: rules})
+
+	pf := rowValues(columns, pessoaFisica)
+	raw := rowValues(DefaultColumns, pessoaFisica)
+	if pf["registroRf"] != "***.982.247-**" {
+		t.Errorf("registroRf = %q, want masked", pf["registroRf"])
+	}
+	for _, name := range []string{"telefone", "logradouro"} {
+		if pf[name] != "" {
+			t.Errorf("%s = %q, want redacted", name, pf[name])
+		}
+	}
+	for _, name := range []string{"id", "nomePrestador"} {
+		if pf[name] == raw[name] || len(pf[name]) != 16 {
+			t.Errorf("%s = %q, want a 16-character hash", name, pf[name])
+		}
+	}
+	for _, name := range []string{"bairro", "municipio", "tipoPessoa"} {
+		if pf[name] != raw[name] {
+			t.Errorf("%s = %q, want kept %q", name, pf[name], raw[name])
+		}
+	}
+
+	pj := rowValues(columns, pessoaJuridica)
+	for name, want := range rowValues(DefaultColumns, pessoaJuridica) {
+		if pj[name] != want {
+			t.Errorf("pessoa jurídica %s = %q, want %q", name, pj[name], want)
+		}
+	}
+}
+
+func TestPrivacyHash(t *testing.T) {
+	a := Privacy{Salt: "segredo"}
+	b := Privacy{Salt: "outro"}
+	h := a.apply(PrivacyHash, "id", "101")
+	if h != a.apply(PrivacyHash, "id", "101") {
+		t.Error("hash is not stable for the same salt")
+	}
+	if h == b.apply(PrivacyHash, "id", "101") {
+		t.Error("hash is the same with another salt")
+	}
+	if h == a.apply(PrivacyHash, "nuPessoa", "101") {
+		t.Error("hash is the same in another column")
+	}
+	if h == a.apply(PrivacyHash, "id", "102") {
+		t.Error("hash is the same for another value")
+	}
+	if got := a.apply(PrivacyHash, "id", " "); got != " " {
+		t.Errorf("hash of a blank value = %q, want it unchanged", got)
+	}
+}
+
+func TestMaskMiddle(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"529.982.247-25", "***.982.247-**"},
+		{"52998224725", "***982247**"},
+		{"1234567", "***45**"},
+		{"123456", "***4**"},
+		{"12345", "*****"},
+		{"ab", "**"},
+		{"a", "*"},
+		{"-.-", "-.-"},
+	}
+	for _, tt := range tests {
+		if got := maskMiddle(tt.in); got != tt.want {
+			t.Errorf("maskMiddle(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFindColumnWithPrivacy(t *testing.T) {
+	rules, err := ParsePrivacyRules("nomePrestador=hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privacy := &Privacy{Salt: "segredo", Rules: rules}
+	// Only município is exported: the split and sample columns are not.
+	exported, _ := FindColumn(nil, nil, "municipio")
+
+	for _, name := range []string{"registroRf", "nomePrestador", "telefone", "documentoFormatado"} {
+		c, ok := FindColumn([]Column{exported}, privacy, name)
+		if !ok {
+			t.Fatalf("FindColumn(%q) not found", name)
+		}
+		raw, _ := ColumnByName(name)
+		v := c.Value(pessoaFisica)
+		if v == raw.Value(pessoaFisica) || strings.Contains(v, "529.982") || strings.Contains(v, "MARIA") {
+			t.Errorf("FindColumn(%q) = %q for pessoa física, want it redacted", name, v)
+		}
+		if got, want := c.Value(pessoaJuridica), raw.Value(pessoaJuridica); got != want {
+			t.Errorf("FindColumn(%q) = %q for pessoa jurídica, want %q", name, got, want)
+		}
+	}
+
+	// Without privacy the raw column is returned.
+	c, _ := FindColumn(nil, nil, "registroRf")
+	if got := c.Value(pessoaFisica); got != "529.982.247-25" {
+		t.Errorf("FindColumn without privacy = %q, want the raw value", got)
+	}
+
+	// An exported column is returned as exported, wrappers included.
+	upper := Column{Name: "nomePrestador", Value: func(p cadastur.Prestador) string { return "exportado" }}
+	if c, _ := FindColumn([]Column{upper}, privacy, "nomePrestador"); c.Value(pessoaFisica) != "exportado" {
+		t.Errorf("FindColumn did not return the exported column")
+	}
+}
+
+func TestSplitWithPrivacy(t *testing.T) {
+	dir := t.TempDir()
+	rules, err := ParsePrivacyRules("nomePrestador=hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privacy := &Privacy{Salt: "segredo", Rules: rules}
+	municipio, _ := ColumnByName("municipio")
+	for _, column := range []string{"registroRf", "nomePrestador"} {
+		w, err := NewSplitWriter(SplitConfig{
+			Column:    column,
+			Template:  filepath.Join(dir, column, "{"+column+"}.csv"),
+			IndexPath: filepath.Join(dir, column, "index.csv"),
+			Output:    Options{Columns: WithPrivacy([]Column{municipio}, *privacy)},
+			Privacy:   privacy,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []cadastur.Prestador{pessoaFisica, pessoaJuridica} {
+			if err := w.WriteRow(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// The file names and the values and files listed in the index.
+		var names []string
+		entries, _ := os.ReadDir(filepath.Join(dir, column))
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		f, err := os.Open(filepath.Join(dir, column, "index.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range records[1:] {
+			names = append(names, r[0], filepath.Base(r[1]))
+		}
+		listing := strings.ToLower(strings.Join(names, " "))
+		for _, leak := range []string{"529", "maria"} {
+			if strings.Contains(listing, leak) {
+				t.Errorf("split by %s leaks %q into file names or index: %s", column, leak, listing)
+			}
+		}
+		// Pessoa jurídica values are not personal data and name their file.
+		if !strings.Contains(listing, "112223330001-81.csv") && !strings.Contains(listing, "agencia-exemplo-ltda.csv") {
+			t.Errorf("split by %s: pessoa jurídica partition missing: %s", column, listing)
+		}
+	}
+}
+
+func TestParsePrivacyRules(t *testing.T) {
+	rules, err := ParsePrivacyRules("nomePrestador=hash, cep=keep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules["nomePrestador"] != PrivacyHash || rules["cep"] != PrivacyKeep || rules["telefone"] != PrivacyRedact {
+		t.Errorf("ParsePrivacyRules = %v", rules)
+	}
+	if DefaultPrivacyRules["cep"] != PrivacyRedact {
+		t.Error("ParsePrivacyRules changed DefaultPrivacyRules")
+	}
+	for _, spec := range []string{"nomePrestador", "nomePrestador=encrypt", "nome=hash"} {
+		if _, err := ParsePrivacyRules(spec); err == nil {
+			t.Errorf("ParsePrivacyRules(%q) succeeded, want an error", spec)
+		}
+	}
+}
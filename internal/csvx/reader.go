@@ -0,0 +1,190 @@
+package csvx
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reader reads rows back from an export file. It accepts CSV and NDJSON,
+// with or without gzip compression, detecting both from the content.
+type Reader struct {
+	file   *os.File
+	gz     *gzip.Reader
+	format Format
+	header []string
+
+	csv  *csv.Reader
+	json *json.Decoder
+	// first holds the NDJSON row consumed while reading the header.
+	first map[string]string
+}
+
+// OpenReader opens an export file for reading.
+func OpenReader(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{file: f}
+
+	br := bufio.NewReader(f)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		r.gz = gz
+		br = bufio.NewReader(gz)
+	}
+
+	// Skip the UTF-8 BOM, then sniff the first meaningful byte: '{' means NDJSON.
+	if bom, _ := br.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	r.format = FormatCSV
+	if b, _ := br.Peek(64); len(bytes.TrimLeft(b, " \t\r\n")) > 0 && bytes.TrimLeft(b, " \t\r\n")[0] == '{' {
+		r.format = FormatNDJSON
+	}
+
+	if err := r.readHeader(br); err != nil {
+		r.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *Reader) readHeader(br *bufio.Reader) error {
+	if r.format == FormatCSV {
+		r.csv = csv.NewReader(br)
+		r.csv.FieldsPerRecord = -1
+		header, err := r.csv.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r.header = header
+		return nil
+	}
+
+	r.json = json.NewDecoder(br)
+	keys, row, err := r.readObject()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.header = keys
+	r.first = row
+	return nil
+}
+
+// Format returns the detected encoding of the file.
+func (r *Reader) Format() Format {
+	return r.format
+}
+
+// Header returns the column names, in file order. For NDJSON they come from the first object.
+func (r *Reader) Header() []string {
+	return r.header
+}
+
+// Read returns the next row keyed by column name, or io.EOF at the end.
+func (r *Reader) Read() (map[string]string, error) {
+	if r.format == FormatNDJSON {
+		if r.first != nil {
+			row := r.first
+			r.first = nil
+			return row, nil
+		}
+		_, row, err := r.readObject()
+		return row, err
+	}
+
+	if r.csv == nil {
+		return nil, io.EOF
+	}
+	rec, err := r.csv.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]string, len(r.header))
+	for i, name := range r.header {
+		if i < len(rec) {
+			row[name] = rec[i]
+		}
+	}
+	return row, nil
+}
+
+// ReadAll reads every remaining row.
+func (r *Reader) ReadAll() ([]map[string]string, error) {
+	var rows []map[string]string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// readObject decodes one flat JSON object, keeping the key order.
+// Non-string values are kept as their JSON text.
+func (r *Reader) readObject() ([]string, map[string]string, error) {
+	tok, err := r.json.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, nil, fmt.Errorf("expected JSON object, got %v", tok)
+	}
+
+	var keys []string
+	row := make(map[string]string)
+	for r.json.More() {
+		tok, err := r.json.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+
+		var raw json.RawMessage
+		if err := r.json.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			s = string(raw)
+			if s == "null" {
+				s = ""
+			}
+		}
+		keys = append(keys, key)
+		row[key] = s
+	}
+	if _, err := r.json.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, row, nil
+}
+
+// Close closes the underlying file.
+func (r *Reader) Close() error {
+	if r.gz != nil {
+		r.gz.Close()
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,149 @@
+package csvx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+// awkward has values that need quoting or escaping in both formats.
+var awkward = cadastur.Prestador{
+	ID:            303,
+	TipoPessoa:    "PJ",
+	NomePrestador: `POUSADA "MAR, SOL" & CIA`,
+	RegistroRf:    "11.222.333/0001-81",
+	NoLogradouro:  "Rua A\nBloco B",
+	Complemento:   `sala 1\2`,
+	NoBairro:      "",
+	Municipio:     "São José",
+}
+
+func TestRoundTrip(t *testing.T) {
+	prestadores := []cadastur.Prestador{pessoaFisica, pessoaJuridica, awkward}
+	for _, name := range []string{"out.csv", "out.csv.gz", "out.ndjson", "out.ndjson.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			w, err := Create(path, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteHeader(); err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range prestadores {
+				if err := w.WriteRow(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			checkFile(t, path, FormatFromPath(name), prestadores)
+		})
+	}
+}
+
+func TestRoundTripAppend(t *testing.T) {
+	for _, name := range []string{"out.csv", "out.csv.gz", "out.ndjson", "out.ndjson.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			w, err := Create(path, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteHeader(); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteRow(pessoaFisica); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			w, err = openAppend(path, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range []cadastur.Prestador{pessoaJuridica, awkward} {
+				if err := w.WriteRow(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			checkFile(t, path, FormatFromPath(name), []cadastur.Prestador{pessoaFisica, pessoaJuridica, awkward})
+			if IsCompressedPath(name) {
+				if n := gzipMembers(t, path); n != 2 {
+					t.Errorf("got %d gzip members, want 2", n)
+				}
+			}
+		})
+	}
+}
+
+// checkFile reads path back and compares it with the DefaultColumns values
+// of want.
+func checkFile(t *testing.T, path string, format Format, want []cadastur.Prestador) {
+	t.Helper()
+	r, err := OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Format() != format {
+		t.Errorf("Format() = %s, want %s", r.Format(), format)
+	}
+	if got, want := strings.Join(r.Header(), ","), strings.Join(ColumnNames(DefaultColumns), ","); got != want {
+		t.Errorf("Header() = %s, want %s", got, want)
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, p := range want {
+		for name, v := range rowValues(DefaultColumns, p) {
+			if rows[i][name] != v {
+				t.Errorf("row %d %s = %q, want %q", i, name, rows[i][name], v)
+			}
+		}
+	}
+}
+
+// gzipMembers counts the gzip members of the file at path.
+func gzipMembers(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		gz.Multistream(false)
+		if _, err := io.Copy(io.Discard, gz); err != nil {
+			t.Fatal(err)
+		}
+		n++
+		if err := gz.Reset(br); err == io.EOF {
+			return n
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package csvx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/normalize"
+)
+
+// DefaultMaxOpenFiles bounds how many partition files a SplitWriter keeps open at once.
+const DefaultMaxOpenFiles = 64
+
+// placeholderRe matches "{column}" placeholders in a partition file name template.
+var placeholderRe = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+
+// SplitConfig configures a SplitWriter.
+type SplitConfig struct {
+	// Column is the header name whose value decides the partition of each row.
+	Column string
+	// Template builds each partition file name; "{column}" placeholders are
+	// replaced by the slugified value of that column for the row. It must
+	// contain the {Column} placeholder. Defaults to DefaultSplitTemplate(Column).
+	Template string
+	// IndexPath is where the partition index is written on Close.
+	// Defaults to "prestadores-index.csv".
+	IndexPath string
+	// MaxOpenFiles bounds the number of simultaneously open partition files.
+	// Defaults to DefaultMaxOpenFiles.
+	MaxOpenFiles int
+	// Output configures the format and compression of every partition file.
+	// The split column and placeholders take their values from
+	// Output.Columns when exported there, so they match the rows (date
+	// format, transforms and privacy included).
+	Output Options
+	// Privacy, when set, also applies to split and placeholder columns that
+	// are not exported, so pessoa física data never reaches file names or
+	// the index.
+	Privacy *Privacy
+}
+
+// DefaultSplitTemplate returns the file name template used when none is given,
+// e.g. "prestadores-{uf}-{municipio}.csv" for column "municipio".
+// The extension follows opts (".ndjson", ".csv.gz", ...).
+func DefaultSplitTemplate(column string, opts Options) string {
+	if column == "uf" {
+		return "prestadores-{uf}" + Extension(opts)
+	}
+	return "prestadores-{uf}-{" + column + "}" + Extension(opts)
+}
+
+// SplitWriter routes rows into separate export files, one per partition.
+// Partition files are opened lazily and the least recently used ones are
+// closed when more than MaxOpenFiles would be open; they are reopened in
+// append mode if more rows arrive later.
+type SplitWriter struct {
+	cfg    SplitConfig
+	column Column
+	// placeholders holds the columns of the template placeholders.
+	placeholders map[string]Column
+	parts        map[string]*partition
+	order        []*partition // creation order, used for the index file
+	open         int
+	tick         int
+	closed       bool
+}
+
+// partition tracks one output file of a SplitWriter.
+type partition struct {
+	value   string
+	path    string
+	rows    int
+	w       RecordWriter // nil while the file is closed
+	lastUse int
+}
+
+// NewSplitWriter validates cfg and returns a SplitWriter. No file is created
+// until the first row of a partition is written.
+func NewSplitWriter(cfg SplitConfig) (*SplitWriter, error) {
+	col, ok := cfg.columnByName(cfg.Column)
+	if !ok {
+		return nil, fmt.Errorf("unknown split column %q", cfg.Column)
+	}
+	if cfg.Template == "" {
+		cfg.Template = DefaultSplitTemplate(cfg.Column, cfg.Output)
+	} else if cfg.Output.Compress && !IsCompressedPath(cfg.Template) {
+		cfg.Template += ".gz"
+	}
+	placeholders := map[string]Column{}
+	for _, m := range placeholderRe.FindAllStringSubmatch(cfg.Template, -1) {
+		c, ok := cfg.columnByName(m[1])
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q in split template", m[1])
+		}
+		placeholders[m[1]] = c
+	}
+	if _, ok := placeholders[cfg.Column]; !ok {
+		return nil, fmt.Errorf("split template %q must contain {%s}", cfg.Template, cfg.Column)
+	}
+	if cfg.IndexPath == "" {
+		cfg.IndexPath = "prestadores-index.csv"
+	}
+	if cfg.MaxOpenFiles <= 0 {
+		cfg.MaxOpenFiles = DefaultMaxOpenFiles
+	}
+
+	return &SplitWriter{
+		cfg:          cfg,
+		column:       col,
+		placeholders: placeholders,
+		parts:        make(map[string]*partition),
+	}, nil
+}
+
+// columnByName finds a split or placeholder column (see FindColumn).
+func (cfg SplitConfig) columnByName(name string) (Column, bool) {
+	return FindColumn(cfg.Output.Columns, cfg.Privacy, name)
+}
+
+// WriteHeader is a no-op: each partition file gets its header when created.
+func (s *SplitWriter) WriteHeader() error {
+	return nil
+}
+
+// WriteRow appends p to the file of its partition, opening it if needed.
+func (s *SplitWriter) WriteRow(p cadastur.Prestador) error {
+	path := s.render(p)
+	part, ok := s.parts[path]
+	if !ok {
+		part = &partition{value: s.column.Value(p), path: path}
+		s.parts[path] = part
+		s.order = append(s.order, part)
+	}
+
+	if part.w == nil {
+		if err := s.openPartition(part); err != nil {
+			return err
+		}
+	}
+
+	s.tick++
+	part.lastUse = s.tick
+	if err := part.w.WriteRow(p); err != nil {
+		return err
+	}
+	part.rows++
+	return nil
+}
+
+// openPartition opens (or reopens) the file of part, evicting the least
+// recently used open partition when the limit is reached.
+func (s *SplitWriter) openPartition(part *partition) error {
+	if s.open >= s.cfg.MaxOpenFiles {
+		if err := s.evict(); err != nil {
+			return err
+		}
+	}
+
+	// A partition with rows was created earlier and then evicted: append to it.
+	if part.rows > 0 {
+		w, err := openAppend(part.path, s.cfg.Output)
+		if err != nil {
+			return err
+		}
+		part.w = w
+		s.open++
+		return nil
+	}
+
+	if dir := filepath.Dir(part.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	w, err := Create(part.path, s.cfg.Output)
+	if err != nil {
+		return err
+	}
+	if err := w.WriteHeader(); err != nil {
+		w.Close()
+		return err
+	}
+	part.w = w
+	s.open++
+	return nil
+}
+
+// evict closes the least recently used open partition.
+func (s *SplitWriter) evict() error {
+	var lru *partition
+	for _, part := range s.order {
+		if part.w != nil && (lru == nil || part.lastUse < lru.lastUse) {
+			lru = part
+		}
+	}
+	if lru == nil {
+		return nil
+	}
+	err := lru.w.Close()
+	lru.w = nil
+	s.open--
+	return err
+}
+
+// render builds the partition file name for p from the template.
+func (s *SplitWriter) render(p cadastur.Prestador) string {
+	return placeholderRe.ReplaceAllStringFunc(s.cfg.Template, func(m string) string {
+		return slugValue(s.placeholders[m[1:len(m)-1]].Value(p))
+	})
+}
+
+// slugValue turns a cell value into a file name fragment ("Florianópolis" -> "florianopolis").
+func slugValue(v string) string {
+	v = strings.TrimSpace(normalize.StripAccents(v))
+	if v == "" {
+		return "sem-valor"
+	}
+	return normalize.Slugify(v)
+}
+
+// Flush flushes every open partition file.
+func (s *SplitWriter) Flush() error {
+	for _, part := range s.order {
+		if part.w == nil {
+			continue
+		}
+		if err := part.w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes all partition files and writes the index file listing each
+// partition with its row count. Calling Close more than once is a no-op.
+func (s *SplitWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	var firstErr error
+	for _, part := range s.order {
+		if part.w == nil {
+			continue
+		}
+		if err := part.w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		part.w = nil
+		s.open--
+	}
+	if err := s.writeIndex(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// Partitions returns the number of partition files created so far.
+func (s *SplitWriter) Partitions() int {
+	return len(s.order)
+}
+
+// IndexPath returns where the partition index is written.
+func (s *SplitWriter) IndexPath() string {
+	return s.cfg.IndexPath
+}
+
+// writeIndex writes the partition index as CSV: partition value, file, rows.
+func (s *SplitWriter) writeIndex() error {
+	f, err := os.Create(s.cfg.IndexPath)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(utf8BOM); err != nil {
+		f.Close()
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{s.cfg.Column, "arquivo", "linhas"})
+	for _, part := range s.order {
+		w.Write([]string{part.value, part.path, strconv.Itoa(part.rows)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,194 @@
+package csvx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cadastur-csv/internal/cadastur"
+)
+
+func TestSplitWriter(t *testing.T) {
+	municipios := []string{"Florianópolis", "SÃO JOSÉ", "Palhoça", "", "../Biguaçu/x", "Tijucas"}
+	files := map[string]string{
+		"Florianópolis": "florianopolis",
+		"SÃO JOSÉ":      "sao-jose",
+		"Palhoça":       "palhoca",
+		"":              "sem-valor",
+		"../Biguaçu/x":  "biguacux",
+		"Tijucas":       "tijucas",
+	}
+	const rounds = 3
+
+	for _, opts := range []Options{
+		{Format: FormatCSV},
+		{Format: FormatCSV, Compress: true},
+		{Format: FormatNDJSON},
+		{Format: FormatNDJSON, Compress: true},
+	} {
+		t.Run(strings.TrimPrefix(Extension(opts), "."), func(t *testing.T) {
+			dir := t.TempDir()
+			uf, _ := ColumnByName("uf")
+			id, _ := ColumnByName("id")
+			municipio, _ := ColumnByName("municipio")
+			opts.Columns = []Column{id, uf, municipio}
+			w, err := NewSplitWriter(SplitConfig{
+				Column:       "municipio",
+				Template:     filepath.Join(dir, "{uf}", "{municipio}"+Extension(Options{Format: opts.Format})),
+				IndexPath:    filepath.Join(dir, "index.csv"),
+				MaxOpenFiles: 2,
+				Output:       opts,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Interleave the partitions so every file is evicted and reopened
+			// in append mode, rounds times.
+			n := 0
+			for round := 0; round < rounds; round++ {
+				for _, m := range municipios {
+					n++
+					p := cadastur.Prestador{ID: cadastur.FlexInt(n), Sguf: "SC", Municipio: cadastur.FlexString(m)}
+					if err := w.WriteRow(p); err != nil {
+						t.Fatal(err)
+					}
+					if w.open > 2 {
+						t.Fatalf("%d files open, want at most 2", w.open)
+					}
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if w.Partitions() != len(municipios) {
+				t.Errorf("Partitions() = %d, want %d", w.Partitions(), len(municipios))
+			}
+
+			// Every file has one header and all its rows, in write order.
+			entries, err := os.ReadDir(filepath.Join(dir, "sc"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(municipios) {
+				t.Errorf("got %d files in sc/, want %d", len(entries), len(municipios))
+			}
+			for i, m := range municipios {
+				path := filepath.Join(dir, "sc", files[m]+Extension(opts))
+				r, err := OpenReader(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rows, err := r.ReadAll()
+				r.Close()
+				if err != nil {
+					t.Fatalf("%s: %v", path, err)
+				}
+				if r.Format() != opts.Format || strings.Join(r.Header(), ",") != "id,uf,municipio" {
+					t.Errorf("%s: format %s, header %v", path, r.Format(), r.Header())
+				}
+				if len(rows) != rounds {
+					t.Fatalf("%s: got %d rows, want %d: %v", path, len(rows), rounds, rows)
+				}
+				for round, row := range rows {
+					if want := fmt.Sprint(round*len(municipios) + i + 1); row["id"] != want || row["municipio"] != m {
+						t.Errorf("%s row %d = %v, want id %s, municipio %q", path, round, row, want, m)
+					}
+				}
+			}
+
+			// The index lists each partition, its file and row count.
+			f, err := os.Open(filepath.Join(dir, "index.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			records, err := csv.NewReader(f).ReadAll()
+			f.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimPrefix(strings.Join(records[0], ","), "\ufeff"); got != "municipio,arquivo,linhas" {
+				t.Errorf("index header = %q", got)
+			}
+			var got, want []string
+			for _, rec := range records[1:] {
+				got = append(got, fmt.Sprintf("%s|%s|%s", rec[0], filepath.Base(rec[1]), rec[2]))
+			}
+			for _, m := range municipios {
+				want = append(want, fmt.Sprintf("%s|%s|%d", m, files[m]+Extension(opts), rounds))
+			}
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("index =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSplitTemplate(t *testing.T) {
+	tests := []struct {
+		column   string
+		template string
+		opts     Options
+		want     string
+	}{
+		{"municipio", "", Options{}, "prestadores-sc-florianopolis.csv"},
+		{"uf", "", Options{Format: FormatNDJSON, Compress: true}, "prestadores-sc.ndjson.gz"},
+		{"municipio", "{municipio}-{atividade}.csv", Options{Compress: true}, "florianopolis-guia-de-turismo.csv.gz"},
+		{"municipio", "{municipio}.csv.gz", Options{Compress: true}, "florianopolis.csv.gz"},
+	}
+	p := cadastur.Prestador{Sguf: "SC", Municipio: "Florianópolis", Atividade: "Guia de Turismo"}
+	for _, tt := range tests {
+		w, err := NewSplitWriter(SplitConfig{Column: tt.column, Template: tt.template, Output: tt.opts})
+		if err != nil {
+			t.Errorf("NewSplitWriter(%q, %q) error = %v", tt.column, tt.template, err)
+			continue
+		}
+		if got := w.render(p); got != tt.want {
+			t.Errorf("render(%q, %q) = %q, want %q", tt.column, tt.template, got, tt.want)
+		}
+	}
+
+	for _, cfg := range []SplitConfig{
+		{Column: "nada"},
+		{Column: "municipio", Template: "{uf}.csv"},
+		{Column: "municipio", Template: "{municipio}-{nada}.csv"},
+	} {
+		if _, err := NewSplitWriter(cfg); err == nil {
+			t.Errorf("NewSplitWriter(%q, %q) succeeded, want an error", cfg.Column, cfg.Template)
+		}
+	}
+}
+
+func TestSplitWriterRewritesRun(t *testing.T) {
+	// A second run over the same template truncates the files instead of
+	// appending to those of the previous run.
+	dir := t.TempDir()
+	template := filepath.Join(dir, "{municipio}.csv")
+	for run := 0; run < 2; run++ {
+		w, err := NewSplitWriter(SplitConfig{Column: "municipio", Template: template, IndexPath: filepath.Join(dir, "index.csv")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteRow(cadastur.Prestador{ID: 1, Municipio: "Tijucas"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := OpenReader(filepath.Join(dir, "tijucas.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != "1" {
+		t.Errorf("rows = %v, want only id 1", rows)
+	}
+}
@@ -0,0 +1,70 @@
+package csvx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/normalize"
+)
+
+// Transforms are the text transforms that can be applied per column.
+var Transforms = map[string]func(string) string{
+	"title": normalize.TitleCasePT,
+	"upper": func(s string) string { return strings.ToUpper(normalize.CollapseSpaces(s)) },
+	"lower": func(s string) string { return strings.ToLower(normalize.CollapseSpaces(s)) },
+	"trim":  normalize.CollapseSpaces,
+}
+
+// ParseTransforms reads a "column=transform,column=transform" spec,
+// e.g. "nomePrestador=title,logradouro=title".
+func ParseTransforms(spec string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		col, name, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid transform %q (use column=transform)", item)
+		}
+		out[strings.TrimSpace(col)] = strings.TrimSpace(name)
+	}
+	return out, nil
+}
+
+// WithTransforms returns a copy of columns where each column named in
+// transforms has its value passed through the selected transform.
+func WithTransforms(columns []Column, transforms map[string]string) ([]Column, error) {
+	out := append([]Column(nil), columns...)
+	for col, name := range transforms {
+		fn, ok := Transforms[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown transform %q for column %q (available: %s)", name, col, strings.Join(transformNames(), ", "))
+		}
+		found := false
+		for i, c := range out {
+			if c.Name != col {
+				continue
+			}
+			value := c.Value
+			out[i].Value = func(p cadastur.Prestador) string { return fn(value(p)) }
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q in transform", col)
+		}
+	}
+	return out, nil
+}
+
+func transformNames() []string {
+	names := make([]string, 0, len(Transforms))
+	for n := range Transforms {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
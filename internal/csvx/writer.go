@@ -2,106 +2,107 @@ package csvx
 
 import (
 	"encoding/csv"
-	"fmt"
-	"os"
 
 	"cadastur-csv/internal/cadastur"
-	"cadastur-csv/internal/normalize"
 )
 
+// utf8BOM is written at the start of every new CSV file.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// RowWriter is implemented by every output sink that accepts Prestador rows.
+type RowWriter interface {
+	WriteHeader() error
+	WriteRow(p cadastur.Prestador) error
+	Flush() error
+	Close() error
+}
+
+// RecordWriter is a RowWriter that also accepts already-rendered rows,
+// with one value per column, e.g. rows read back from another export.
+type RecordWriter interface {
+	RowWriter
+	WriteRecord(values []string) error
+}
+
 // Writer handles CSV file writing with header and row normalization.
 type Writer struct {
-	file   *os.File
-	writer *csv.Writer
+	out     *output
+	writer  *csv.Writer
+	columns []Column
+	closed  bool
 }
 
 // NewWriter creates a new CSV writer for the specified filename.
-func NewWriter(filename string) (*Writer, error) {
-	f, err := os.Create(filename)
+// The file is gzip-compressed when opts.Compress is set or the name ends in ".gz".
+func NewWriter(filename string, opts Options) (*Writer, error) {
+	out, err := createOutput(filename, opts.Compress, false)
 	if err != nil {
 		return nil, err
 	}
 
 	// Write UTF-8 BOM so Excel on Windows detects UTF-8 encoding when opening the CSV.
 	// This helps avoid mojibake like "Ã¡" when users open the CSV by double-clicking in Explorer.
-	if _, err := f.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
-		f.Close()
+	// For compressed files the BOM goes inside the gzip stream.
+	if _, err := out.Write(utf8BOM); err != nil {
+		out.close()
 		return nil, err
 	}
 
-	w := csv.NewWriter(f)
+	return newWriter(out, opts.columns()), nil
+}
 
+func newWriter(out *output, columns []Column) *Writer {
 	return &Writer{
-		file:   f,
-		writer: w,
-	}, nil
+		out:     out,
+		writer:  csv.NewWriter(out),
+		columns: columns,
+	}
 }
 
-// WriteHeader writes the CSV header once.
-// Header must match exactly: id,tipoPessoa,numeroCadastro,inicioVigencia,fimVigencia,website,telefone,logradouro,complemento,cep,uf,bairro,nomePrestador,registroRf,nuAtividadeTuristica,atividade,nuSituacaoCadastral,situacao,nuUf,localidadeNuUf,municipio,localidade,noLocalidade,nuLocalidade,nuMunicipio,nuPessoa,possuiVeiculo,nuSitCadTramite,atividadeRedeSociais
+// WriteHeader writes the CSV header once, using the column names.
 func (w *Writer) WriteHeader() error {
-	header := []string{
-		"id", "tipoPessoa", "numeroCadastro",
-		"inicioVigencia", "fimVigencia",
-		"website", "telefone",
-		"logradouro", "complemento", "cep", "uf", "bairro",
-		"nomePrestador", "registroRf",
-		"nuAtividadeTuristica", "atividade",
-		"nuSituacaoCadastral", "situacao",
-		"nuUf", "localidadeNuUf",
-		"municipio", "localidade", "noLocalidade",
-		"nuLocalidade", "nuMunicipio", "nuPessoa",
-		"possuiVeiculo", "nuSitCadTramite", "atividadeRedeSociais",
-	}
-	return w.writer.Write(header)
+	return w.writer.Write(ColumnNames(w.columns))
 }
 
 // WriteRow writes a normalized row for a Prestador.
-// Normalizes telephone and CEP to digits only, handles dates, bools, and pointers.
 func (w *Writer) WriteRow(p cadastur.Prestador) error {
-	row := []string{
-		fmt.Sprint(p.ID),
-		p.TipoPessoa,
-		// Prefix with apostrophe to force Excel to treat as text and preserve all digits.
-		// The apostrophe won't appear in Excel but keeps numbers from being reformatted.
-		p.NumeroCadastro,
-		normalize.MsToDate(p.DtInicioVigencia),
-		normalize.MsToDate(p.DtFimVigencia),
-		normalize.EmptyIfNil(p.NoWebSite),
-		normalize.OnlyDigits(p.NuTelefone),
-		normalize.FixMojibake(p.NoLogradouro),
-		normalize.FixMojibake(p.Complemento),
-		normalize.OnlyDigits(p.NuCep),
-		p.Sguf,
-		normalize.FixMojibake(p.NoBairro),
-		normalize.FixMojibake(p.NomePrestador),
-		p.RegistroRf,
-		fmt.Sprint(p.NuAtividadeTuristica),
-		normalize.FixMojibake(p.Atividade),
-		fmt.Sprint(p.NuSituacaoCadastral),
-		normalize.FixMojibake(p.Situacao),
-		fmt.Sprint(p.NuUf),
-		normalize.IntPtrToStr(p.LocalidadeNuUf),
-		normalize.FixMojibake(p.Municipio),
-		normalize.FixMojibake(p.Localidade),
-		normalize.FixMojibake(p.NoLocalidade),
-		fmt.Sprint(p.NuLocalidade),
-		fmt.Sprint(p.NuMunicipio),
-		fmt.Sprint(p.NuPessoa),
-		normalize.BoolToStr(p.FlPossuiVeiculo),
-		fmt.Sprint(p.NuSitCadTramite),
-		normalize.FixMojibake(normalize.EmptyIfNil(p.AtividadeRedeSociais)),
-	}
-	return w.writer.Write(row)
+	return w.writer.Write(renderRow(w.columns, p))
 }
 
-// Flush flushes the CSV writer buffer.
+// WriteRecord writes an already-rendered row.
+func (w *Writer) WriteRecord(values []string) error {
+	return w.writer.Write(values)
+}
+
+// Flush flushes the CSV writer buffer (and the gzip stream, if any).
 func (w *Writer) Flush() error {
 	w.writer.Flush()
-	return w.writer.Error()
+	if err := w.writer.Error(); err != nil {
+		return err
+	}
+	return w.out.flush()
 }
 
-// Close closes the underlying file.
+// Close flushes any buffered rows and closes the underlying file.
+// Calling Close more than once is a no-op.
 func (w *Writer) Close() error {
-	return w.file.Close()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.out.close()
+		return err
+	}
+	return w.out.close()
+}
+
+// renderRow computes the cell values of p for the given columns.
+func renderRow(columns []Column, p cadastur.Prestador) []string {
+	row := make([]string, len(columns))
+	for i, c := range columns {
+		row[i] = c.Value(p)
+	}
+	return row
 }
@@ -0,0 +1,175 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// FieldChange is one field whose value differs between two snapshots.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Changed is a provider present in both snapshots with at least one changed field.
+type Changed struct {
+	Key    string        `json:"key"`
+	Name   string        `json:"nomePrestador"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// Summary holds the counts of a comparison.
+type Summary struct {
+	Old       int `json:"old"`
+	New       int `json:"new"`
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// Result is the outcome of comparing two exports keyed by one column.
+type Result struct {
+	Key     string              `json:"key"`
+	Summary Summary             `json:"summary"`
+	Added   []map[string]string `json:"added"`
+	Removed []map[string]string `json:"removed"`
+	Changed []Changed           `json:"changed"`
+}
+
+// Options configures Compare.
+type Options struct {
+	// Key is the column identifying a provider, e.g. "id" or "numeroCadastro".
+	Key string
+	// Fields restricts the compared columns; empty means every column of
+	// either export, so a column dropped from the new one shows as changed.
+	Fields []string
+	// Ignore lists columns never compared.
+	Ignore []string
+}
+
+// Compare matches rows of the old and new exports by opts.Key and reports
+// added, removed and changed providers. Rows with an empty key are skipped;
+// when a key repeats, the last row wins. Results are sorted by key.
+func Compare(oldHeader []string, oldRows []map[string]string, newHeader []string, newRows []map[string]string, opts Options) (Result, error) {
+	if !contains(oldHeader, opts.Key) {
+		return Result{}, fmt.Errorf("key column %q not found in old export", opts.Key)
+	}
+	if !contains(newHeader, opts.Key) {
+		return Result{}, fmt.Errorf("key column %q not found in new export", opts.Key)
+	}
+
+	columns := union(newHeader, oldHeader)
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = columns
+	}
+	for _, f := range append(slices.Clone(fields), opts.Ignore...) {
+		if !contains(columns, f) {
+			return Result{}, fmt.Errorf("unknown column %q (not in either export)", f)
+		}
+	}
+	compared := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f != opts.Key && !contains(opts.Ignore, f) {
+			compared = append(compared, f)
+		}
+	}
+
+	oldByKey := index(oldRows, opts.Key)
+	newByKey := index(newRows, opts.Key)
+
+	res := Result{Key: opts.Key}
+	res.Summary.Old = len(oldByKey)
+	res.Summary.New = len(newByKey)
+
+	for _, k := range sortedKeys(newByKey) {
+		after := newByKey[k]
+		before, ok := oldByKey[k]
+		if !ok {
+			res.Added = append(res.Added, after)
+			continue
+		}
+
+		var changes []FieldChange
+		for _, f := range compared {
+			if before[f] != after[f] {
+				changes = append(changes, FieldChange{Field: f, Before: before[f], After: after[f]})
+			}
+		}
+		if len(changes) == 0 {
+			res.Summary.Unchanged++
+			continue
+		}
+		res.Changed = append(res.Changed, Changed{Key: k, Name: after["nomePrestador"], Fields: changes})
+	}
+
+	for _, k := range sortedKeys(oldByKey) {
+		if _, ok := newByKey[k]; !ok {
+			res.Removed = append(res.Removed, oldByKey[k])
+		}
+	}
+
+	res.Summary.Added = len(res.Added)
+	res.Summary.Removed = len(res.Removed)
+	res.Summary.Changed = len(res.Changed)
+	return res, nil
+}
+
+func index(rows []map[string]string, key string) map[string]map[string]string {
+	m := make(map[string]map[string]string, len(rows))
+	for _, row := range rows {
+		if k := row[key]; k != "" {
+			m[k] = row
+		}
+	}
+	return m
+}
+
+// union returns the columns of a followed by those only in b, in file order.
+func union(a, b []string) []string {
+	out := slices.Clone(a)
+	for _, c := range b {
+		if !contains(out, c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// sortedKeys orders integer keys numerically, before every other key, and
+// the other keys lexically.
+func sortedKeys(m map[string]map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, compareKeys)
+	return keys
+}
+
+func compareKeys(a, b string) int {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Or(cmp.Compare(x, y), cmp.Compare(a, b))
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return cmp.Compare(a, b)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
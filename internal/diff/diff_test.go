@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	oldHeader := []string{"id", "nomePrestador", "telefone"}
+	oldRows := []map[string]string{
+		{"id": "10", "nomePrestador": "A", "telefone": "1"},
+		{"id": "9", "nomePrestador": "B", "telefone": "2"},
+		{"id": "x1", "nomePrestador": "C", "telefone": "3"},
+	}
+	newHeader := []string{"id", "nomePrestador", "municipio"}
+	newRows := []map[string]string{
+		{"id": "10", "nomePrestador": "A", "municipio": "Joinville"},
+		{"id": "9", "nomePrestador": "B2", "municipio": ""},
+		{"id": "100", "nomePrestador": "D", "municipio": ""},
+		{"id": "", "nomePrestador": "sem chave"},
+	}
+
+	res, err := Compare(oldHeader, oldRows, newHeader, newRows, Options{Key: "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Summary{Old: 3, New: 3, Added: 1, Removed: 1, Changed: 2}
+	if res.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", res.Summary, want)
+	}
+	var changed []string
+	for _, c := range res.Changed {
+		for _, f := range c.Fields {
+			changed = append(changed, c.Key+"."+f.Field)
+		}
+	}
+	// telefone is only in the old export and still compared.
+	if want := []string{"9.nomePrestador", "9.telefone", "10.municipio", "10.telefone"}; !slices.Equal(changed, want) {
+		t.Errorf("changed = %q, want %q", changed, want)
+	}
+
+	res, err = Compare(oldHeader, oldRows, newHeader, newRows, Options{Key: "id", Fields: []string{"nomePrestador"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Summary.Changed != 1 || res.Summary.Unchanged != 1 {
+		t.Errorf("with Fields: Summary = %+v, want 1 changed and 1 unchanged", res.Summary)
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	header := []string{"id", "nomePrestador"}
+	tests := []struct {
+		opts Options
+		want string
+	}{
+		{Options{Key: "numeroCadastro"}, `key column "numeroCadastro" not found`},
+		{Options{Key: "id", Fields: []string{"nome"}}, `unknown column "nome"`},
+		{Options{Key: "id", Ignore: []string{"telefone"}}, `unknown column "telefone"`},
+	}
+	for _, tt := range tests {
+		_, err := Compare(header, nil, header, nil, tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compare(%+v) error = %v, want %q", tt.opts, err, tt.want)
+		}
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	m := map[string]map[string]string{}
+	for _, k := range []string{"b", "10", "a10", "9", "-1", "007", "7", "a9"} {
+		m[k] = nil
+	}
+	want := []string{"-1", "007", "7", "9", "10", "a10", "a9", "b"}
+	if got := sortedKeys(m); !slices.Equal(got, want) {
+		t.Errorf("sortedKeys = %q, want %q", got, want)
+	}
+}
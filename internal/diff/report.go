@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteCSV writes one line per added/removed provider and one line per changed field:
+// change,key,nomePrestador,field,before,after
+func WriteCSV(w io.Writer, res Result) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"change", res.Key, "nomePrestador", "field", "before", "after"})
+	for _, row := range res.Added {
+		cw.Write([]string{"added", row[res.Key], row["nomePrestador"], "", "", ""})
+	}
+	for _, row := range res.Removed {
+		cw.Write([]string{"removed", row[res.Key], row["nomePrestador"], "", "", ""})
+	}
+	for _, c := range res.Changed {
+		for _, f := range c.Fields {
+			cw.Write([]string{"changed", c.Key, c.Name, f.Field, f.Before, f.After})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the full result, including summary counts, as indented JSON.
+func WriteJSON(w io.Writer, res Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// WriteReport writes a human-readable report in Portuguese.
+func WriteReport(w io.Writer, res Result) error {
+	var b strings.Builder
+	s := res.Summary
+	fmt.Fprintf(&b, "Comparação por %s\n", res.Key)
+	fmt.Fprintf(&b, "Antes: %d | Depois: %d | Novos: %d | Removidos: %d | Alterados: %d | Sem alteração: %d\n",
+		s.Old, s.New, s.Added, s.Removed, s.Changed, s.Unchanged)
+
+	if len(res.Added) > 0 {
+		fmt.Fprintf(&b, "\nNovos (%d)\n", len(res.Added))
+		for _, row := range res.Added {
+			fmt.Fprintf(&b, "  + %s %s (%s)\n", row[res.Key], row["nomePrestador"], row["municipio"])
+		}
+	}
+	if len(res.Removed) > 0 {
+		fmt.Fprintf(&b, "\nRemovidos (%d)\n", len(res.Removed))
+		for _, row := range res.Removed {
+			fmt.Fprintf(&b, "  - %s %s (%s)\n", row[res.Key], row["nomePrestador"], row["municipio"])
+		}
+	}
+	if len(res.Changed) > 0 {
+		fmt.Fprintf(&b, "\nAlterados (%d)\n", len(res.Changed))
+		for _, c := range res.Changed {
+			fmt.Fprintf(&b, "  ~ %s %s\n", c.Key, c.Name)
+			for _, f := range c.Fields {
+				fmt.Fprintf(&b, "      %s: %q → %q\n", f.Field, f.Before, f.After)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
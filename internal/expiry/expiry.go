@@ -0,0 +1,127 @@
+package expiry
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"cadastur-csv/internal/normalize"
+)
+
+// Entry is one provider whose registration expires soon or has expired while active.
+type Entry struct {
+	Key         string    `json:"key"`
+	Nome        string    `json:"nomePrestador"`
+	Municipio   string    `json:"municipio"`
+	Situacao    string    `json:"situacao"`
+	Telefone    string    `json:"telefone"`
+	FimVigencia time.Time `json:"fimVigencia"`
+	// DaysLeft is negative for registrations that already expired.
+	DaysLeft int  `json:"diasRestantes"`
+	Expired  bool `json:"vencido"`
+}
+
+// Group lists the entries of one município, soonest expiry first.
+type Group struct {
+	Municipio string  `json:"municipio"`
+	Entries   []Entry `json:"entries"`
+}
+
+// Report is the result of Build.
+type Report struct {
+	Reference     time.Time `json:"reference"`
+	Days          int       `json:"days"`
+	Expiring      int       `json:"expiring"`
+	ExpiredActive int       `json:"expiredActive"`
+	// Skipped counts rows without a readable fimVigencia.
+	Skipped int     `json:"skipped"`
+	Groups  []Group `json:"groups"`
+}
+
+// inactiveMarkers identify a situação that no longer counts as active.
+var inactiveMarkers = []string{"cancel", "inativ", "vencid", "suspens", "baixad", "encerrad"}
+
+// IsActive reports whether a situação text describes an active registration.
+// Anything not matching a known inactive marker ("Cancelado", "Vencido", ...) counts as active.
+func IsActive(situacao string) bool {
+	s := strings.ToLower(normalize.StripAccents(situacao))
+	if strings.TrimSpace(s) == "" {
+		return false
+	}
+	for _, m := range inactiveMarkers {
+		if strings.Contains(s, m) {
+			return false
+		}
+	}
+	return true
+}
+
+// Build selects, from exported rows, providers whose fimVigencia falls within
+// the next days days (counting from ref), plus those already expired while
+// their situação is still active. Rows are keyed by keyField and grouped by município.
+// keyField and fimVigencia must be columns of header.
+func Build(header []string, rows []map[string]string, keyField string, ref time.Time, days int) (Report, error) {
+	for _, name := range []string{keyField, "fimVigencia"} {
+		if !slices.Contains(header, name) {
+			return Report{}, fmt.Errorf("column %q not found in export", name)
+		}
+	}
+
+	today := civilDate(ref.In(normalize.CadasturLocation))
+	rep := Report{Reference: today, Days: days}
+	byMunicipio := make(map[string][]Entry)
+
+	for _, row := range rows {
+		t, ok := normalize.ParseDate(row["fimVigencia"])
+		if !ok {
+			rep.Skipped++
+			continue
+		}
+		fim := civilDate(t)
+
+		left := int(fim.Sub(today).Hours() / 24)
+		expired := left < 0
+		switch {
+		case expired && IsActive(row["situacao"]):
+			rep.ExpiredActive++
+		case !expired && left <= days:
+			rep.Expiring++
+		default:
+			continue
+		}
+
+		e := Entry{
+			Key:         row[keyField],
+			Nome:        row["nomePrestador"],
+			Municipio:   row["municipio"],
+			Situacao:    row["situacao"],
+			Telefone:    row["telefone"],
+			FimVigencia: fim,
+			DaysLeft:    left,
+			Expired:     expired,
+		}
+		byMunicipio[e.Municipio] = append(byMunicipio[e.Municipio], e)
+	}
+
+	for m, entries := range byMunicipio {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].DaysLeft != entries[j].DaysLeft {
+				return entries[i].DaysLeft < entries[j].DaysLeft
+			}
+			return entries[i].Nome < entries[j].Nome
+		})
+		rep.Groups = append(rep.Groups, Group{Municipio: m, Entries: entries})
+	}
+	sort.Slice(rep.Groups, func(i, j int) bool {
+		return rep.Groups[i].Municipio < rep.Groups[j].Municipio
+	})
+	return rep, nil
+}
+
+// civilDate drops the time of day, keeping the calendar date of t (as UTC
+// midnight, so differences between dates are whole days).
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,116 @@
+package expiry
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cadastur-csv/internal/normalize"
+)
+
+var header = []string{"id", "numeroCadastro", "nomePrestador", "municipio", "situacao", "fimVigencia"}
+
+func row(id, municipio, situacao, fim string) map[string]string {
+	return map[string]string{
+		"id":             id,
+		"numeroCadastro": "SC" + id,
+		"nomePrestador":  "PRESTADOR " + id,
+		"municipio":      municipio,
+		"situacao":       situacao,
+		"fimVigencia":    fim,
+	}
+}
+
+func TestBuild(t *testing.T) {
+	// Late in the evening, Brasília time: already the next day in UTC.
+	ref := time.Date(2026, 3, 10, 23, 30, 0, 0, normalize.CadasturLocation)
+	rows := []map[string]string{
+		row("1", "Tijucas", "Em Operação", "2026-04-09"),                // exactly 30 days
+		row("2", "Tijucas", "Em Operação", "2026-04-10"),                // 31 days, outside
+		row("3", "Florianópolis", "Em Operação", "10/03/2026"),          // today
+		row("4", "Florianópolis", "Em Operação", "2026-03-09"),          // expired, still active
+		row("5", "Florianópolis", "Cancelado", "2026-01-01"),            // expired and inactive
+		row("6", "Florianópolis", "", "2026-01-01"),                     // expired, no situação
+		row("7", "Palhoça", "Em Operação", "2026-03-20T10:00:00-03:00"), // 10 days
+		row("8", "Palhoça", "Em Operação", "31/13/2026"),
+		row("9", "Palhoça", "Em Operação", ""),
+		row("10", "Palhoça", "Vencido", "2026-03-01"),
+	}
+
+	rep, err := Build(header, rows, "numeroCadastro", ref, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rep.Reference.Format("2006-01-02"); got != "2026-03-10" {
+		t.Errorf("Reference = %s, want 2026-03-10", got)
+	}
+	if rep.Expiring != 3 || rep.ExpiredActive != 1 || rep.Skipped != 2 {
+		t.Errorf("expiring, expired active, skipped = %d, %d, %d, want 3, 1, 2", rep.Expiring, rep.ExpiredActive, rep.Skipped)
+	}
+
+	var got []string
+	for _, g := range rep.Groups {
+		for _, e := range g.Entries {
+			got = append(got, fmt.Sprintf("%s:%s:%d:%v", g.Municipio, e.Key, e.DaysLeft, e.Expired))
+		}
+	}
+	want := []string{
+		"Florianópolis:SC4:-1:true",
+		"Florianópolis:SC3:0:false",
+		"Palhoça:SC7:10:false",
+		"Tijucas:SC1:30:false",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("entries = %v, want %v", got, want)
+	}
+
+	// A zero-day window only keeps today and the expired active rows.
+	rep, err = Build(header, rows, "id", ref, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Expiring != 1 || rep.ExpiredActive != 1 {
+		t.Errorf("days 0: expiring, expired active = %d, %d, want 1, 1", rep.Expiring, rep.ExpiredActive)
+	}
+}
+
+func TestBuildMissingColumn(t *testing.T) {
+	ref := time.Date(2026, 3, 10, 12, 0, 0, 0, normalize.CadasturLocation)
+	rows := []map[string]string{row("1", "Tijucas", "Em Operação", "2026-04-09")}
+	tests := []struct {
+		header []string
+		key    string
+	}{
+		{header, "nuPessoa"},
+		{header, ""},
+		{[]string{"id", "municipio"}, "id"},
+	}
+	for _, tt := range tests {
+		if _, err := Build(tt.header, rows, tt.key, ref, 30); err == nil {
+			t.Errorf("Build(%v, key %q) succeeded, want an error", tt.header, tt.key)
+		}
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"Em Operação", true},
+		{"Ativo", true},
+		{"Cancelado", false},
+		{"INATIVO", false},
+		{"Vencido", false},
+		{"Suspenso", false},
+		{"Baixada", false},
+		{"Encerrado", false},
+		{" ", false},
+	}
+	for _, tt := range tests {
+		if got := IsActive(tt.in); got != tt.want {
+			t.Errorf("IsActive(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
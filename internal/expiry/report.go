@@ -0,0 +1,62 @@
+package expiry
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteReport writes the report as plain text grouped by município, ready to
+// paste into an e-mail to the regional offices.
+func WriteReport(w io.Writer, rep Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Vencimento do cadastro — referência %s, próximos %d dias\n", rep.Reference.Format("02/01/2006"), rep.Days)
+	fmt.Fprintf(&b, "A vencer: %d | Vencidos ainda ativos: %d | Municípios: %d\n", rep.Expiring, rep.ExpiredActive, len(rep.Groups))
+	if rep.Skipped > 0 {
+		fmt.Fprintf(&b, "Linhas sem data de fim de vigência: %d\n", rep.Skipped)
+	}
+
+	for _, g := range rep.Groups {
+		name := g.Municipio
+		if name == "" {
+			name = "(município não informado)"
+		}
+		fmt.Fprintf(&b, "\n%s (%d)\n", name, len(g.Entries))
+		for _, e := range g.Entries {
+			status := fmt.Sprintf("vence em %d dias", e.DaysLeft)
+			if e.Expired {
+				status = fmt.Sprintf("VENCIDO há %d dias, situação %q", -e.DaysLeft, e.Situacao)
+			}
+			fmt.Fprintf(&b, "  %s  %s — %s (%s)\n", e.FimVigencia.Format("02/01/2006"), e.Nome, status, e.Key)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, rep Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// WriteCSV writes one line per entry, ordered by município.
+func WriteCSV(w io.Writer, rep Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"municipio", "chave", "nomePrestador", "situacao", "telefone", "fimVigencia", "diasRestantes", "vencido"})
+	for _, g := range rep.Groups {
+		for _, e := range g.Entries {
+			cw.Write([]string{
+				g.Municipio, e.Key, e.Nome, e.Situacao, e.Telefone,
+				e.FimVigencia.Format("2006-01-02"), strconv.Itoa(e.DaysLeft), strconv.FormatBool(e.Expired),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,114 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// lowerParticles stay lowercase inside names ("Rua das Flores", "Pedro e Paulo").
+var lowerParticles = map[string]bool{
+	"de": true, "da": true, "do": true, "das": true, "dos": true, "e": true,
+}
+
+// upperAbbreviations keep their uppercase form, whatever the input casing.
+var upperAbbreviations = map[string]string{
+	"ltda": "LTDA", "ltda.": "LTDA.", "epp": "EPP", "eireli": "EIRELI",
+	"mei": "MEI", "slu": "SLU", "s/a": "S/A", "s.a.": "S.A.", "s.a": "S.A",
+	"cnpj": "CNPJ", "cpf": "CPF",
+}
+
+// suffixAbbreviations are company suffixes that are also ordinary words
+// ("me", "sa"); they are only uppercased as the last word of a name.
+var suffixAbbreviations = map[string]string{
+	"me": "ME", "sa": "SA",
+}
+
+// romanRe matches Roman numerals up to 89 (I..LXXXIX), as used in street and
+// dynasty names ("Rua XV de Novembro", "Pio XII").
+var romanRe = regexp.MustCompile(`^(?i)(L?X{0,3})(IX|IV|V?I{0,3})$`)
+
+// streetTypes are the words after which a Roman numeral is expected
+// ("Rua XV de Novembro", "Av. VII de Setembro").
+var streetTypes = map[string]bool{
+	"rua": true, "r.": true, "av": true, "av.": true, "avenida": true,
+	"travessa": true, "trav.": true, "tv.": true, "praça": true, "praca": true,
+	"pça": true, "pça.": true, "alameda": true, "al.": true, "rodovia": true,
+	"rod.": true, "estrada": true, "largo": true, "beco": true,
+	"servidão": true, "servidao": true,
+}
+
+// romanLookalikes are words that read as Roman numerals but are more often
+// words or surnames ("vi", "li", "xi"); at the end of a name they keep the
+// regular casing.
+var romanLookalikes = map[string]bool{
+	"vi": true, "li": true, "xi": true, "lx": true, "l": true,
+}
+
+// CollapseSpaces trims s and replaces every run of whitespace with one space.
+func CollapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// TitleCasePT title-cases Brazilian Portuguese names and addresses: every
+// word is capitalized except the particles de/da/do/das/dos/e (unless first),
+// company abbreviations (LTDA, EIRELI, S/A..., and ME or SA as the last
+// word) are uppercased, and repeated whitespace is collapsed. Roman numerals
+// are uppercased only where they are expected: right after a street type
+// ("Rua XV de Novembro"), at the end of a name ("Pio XII") or at its start
+// when more words follow ("IV Centenário"); in the last two places words
+// such as "Vi" and "Li" are left alone.
+func TitleCasePT(s string) string {
+	words := strings.Fields(s)
+	// last is the last word that is not a company suffix, so "Pio XII LTDA"
+	// still ends in a numeral.
+	last := len(words) - 1
+	for last > 0 && isCompanySuffix(words[last], last == len(words)-1) {
+		last--
+	}
+	for i, w := range words {
+		lw := strings.ToLower(w)
+		switch {
+		case upperAbbreviations[lw] != "":
+			words[i] = upperAbbreviations[lw]
+		case i > 0 && i == len(words)-1 && suffixAbbreviations[lw] != "":
+			words[i] = suffixAbbreviations[lw]
+		case i > 0 && lowerParticles[lw]:
+			words[i] = lw
+		case i > 0 && romanRe.MatchString(w) && (streetTypes[strings.ToLower(words[i-1])] || i == last && !romanLookalikes[lw]):
+			words[i] = strings.ToUpper(w)
+		case i == 0 && i < last && romanRe.MatchString(w) && !romanLookalikes[lw]:
+			words[i] = strings.ToUpper(w)
+		default:
+			words[i] = capitalizeWord(lw)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// isCompanySuffix reports whether w is a company abbreviation; ME and SA only
+// count as the last word.
+func isCompanySuffix(w string, isLast bool) bool {
+	lw := strings.ToLower(w)
+	return upperAbbreviations[lw] != "" || isLast && suffixAbbreviations[lw] != ""
+}
+
+// capitalizeWord uppercases the first letter of w and of every part after a
+// hyphen or apostrophe ("guarda-mor" -> "Guarda-Mor", "d'água" -> "D'Água").
+func capitalizeWord(w string) string {
+	out := []rune(w)
+	upperNext := true
+	for i, r := range out {
+		if upperNext && unicode.IsLetter(r) {
+			out[i] = unicode.ToUpper(r)
+			upperNext = false
+			continue
+		}
+		if r == '-' || r == '\'' || r == '’' || r == '(' || r == '/' {
+			upperNext = true
+		} else if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			upperNext = false
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,37 @@
+package normalize
+
+import "testing"
+
+func TestTitleCasePT(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"RUA DAS  FLORES", "Rua das Flores"},
+		{"pedro e paulo turismo ltda", "Pedro e Paulo Turismo LTDA"},
+		{"guarda-mor d'água", "Guarda-Mor D'Água"},
+		// Roman numerals after a street type, at the end of a name...
+		{"rua xv de novembro", "Rua XV de Novembro"},
+		{"AV. VII DE SETEMBRO", "Av. VII de Setembro"},
+		{"colegio pio xii", "Colegio Pio XII"},
+		{"hotel dom pedro ii me", "Hotel Dom Pedro II ME"},
+		// ...or at the start of a name followed by more words.
+		{"IV CENTENARIO", "IV Centenario"},
+		{"xv de novembro hotel", "XV de Novembro Hotel"},
+		// Words that look like numerals elsewhere keep the regular casing.
+		{"ana li", "Ana Li"},
+		{"vi mar turismo", "Vi Mar Turismo"},
+		{"xi lanches", "Xi Lanches"},
+		{"casa di vinci", "Casa Di Vinci"},
+		// ME and SA are company suffixes only as the last word.
+		{"me leva turismo", "Me Leva Turismo"},
+		{"sa pousada", "Sa Pousada"},
+		{"viagens brasil sa", "Viagens Brasil SA"},
+		{"ACME TURISMO EIRELI", "Acme Turismo EIRELI"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := TitleCasePT(tt.in); got != tt.want {
+			t.Errorf("TitleCasePT(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package normalize
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+)
+
+// CEP validation results reported by CheckCEP.
+const (
+	CEPValid      = "valido"
+	CEPMissing    = "ausente"
+	CEPInvalid    = "invalido"
+	CEPUFMismatch = "uf-divergente"
+)
+
+// cepRangesCSV is the Correios CEP range of each UF (uf,inicio,fim).
+//
+//go:embed cep_faixas.csv
+var cepRangesCSV string
+
+type cepRange struct {
+	uf         string
+	start, end int
+}
+
+var cepRanges = loadCEPRanges()
+
+func loadCEPRanges() []cepRange {
+	records, err := csv.NewReader(strings.NewReader(cepRangesCSV)).ReadAll()
+	if err != nil {
+		panic("normalize: invalid embedded CEP table: " + err.Error())
+	}
+	ranges := make([]cepRange, 0, len(records))
+	for _, r := range records[1:] {
+		start, err1 := strconv.Atoi(r[1])
+		end, err2 := strconv.Atoi(r[2])
+		if err1 != nil || err2 != nil {
+			panic("normalize: invalid embedded CEP range for " + r[0])
+		}
+		ranges = append(ranges, cepRange{uf: r[0], start: start, end: end})
+	}
+	return ranges
+}
+
+// cepDigits returns the digits of a CEP. A bare 7-digit number is a CEP that
+// lost its leading zero on the way through a JSON number (1310100 for
+// 01310-100), so it is padded back to 8 digits.
+func cepDigits(s string) string {
+	d := OnlyDigits(s)
+	if len(d) == 7 && strings.TrimSpace(s) == d {
+		d = "0" + d
+	}
+	return d
+}
+
+// FormatCEP renders a CEP as NNNNN-NNN. Returns "" when it does not have 8 digits.
+func FormatCEP(s string) string {
+	d := cepDigits(s)
+	if len(d) != 8 {
+		return ""
+	}
+	return d[:5] + "-" + d[5:]
+}
+
+// CEPUF returns the UF whose CEP range contains the CEP, or "" if none does.
+func CEPUF(s string) string {
+	d := cepDigits(s)
+	if len(d) != 8 {
+		return ""
+	}
+	n, _ := strconv.Atoi(d)
+	for _, r := range cepRanges {
+		if n >= r.start && n <= r.end {
+			return r.uf
+		}
+	}
+	return ""
+}
+
+// CheckCEP validates a CEP against the provider's UF. It returns CEPValid,
+// CEPMissing (empty), CEPInvalid (not 8 digits or outside every range) or
+// CEPUFMismatch (the range belongs to another UF).
+func CheckCEP(cep, uf string) string {
+	d := cepDigits(cep)
+	if d == "" {
+		return CEPMissing
+	}
+	cepUF := ""
+	if len(d) == 8 {
+		cepUF = CEPUF(d)
+	}
+	if cepUF == "" {
+		return CEPInvalid
+	}
+	if uf = strings.ToUpper(strings.TrimSpace(uf)); uf != "" && uf != cepUF {
+		return CEPUFMismatch
+	}
+	return CEPValid
+}
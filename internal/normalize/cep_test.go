@@ -0,0 +1,63 @@
+package normalize
+
+import "testing"
+
+func TestFormatCEP(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"01310-100", "01310-100"},
+		{"01310100", "01310-100"},
+		{"88.010-000", "88010-000"},
+		{"1310100", "01310-100"},
+		{" 1310100 ", "01310-100"},
+		{"1310-100", ""},
+		{"131010", ""},
+		{"013101000", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := FormatCEP(tt.in); got != tt.want {
+			t.Errorf("FormatCEP(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCEP(t *testing.T) {
+	tests := []struct{ cep, uf, want string }{
+		{"01310-100", "SP", CEPValid},
+		{"1310100", "SP", CEPValid},
+		{"88010-000", "sc", CEPValid},
+		{"69900-000", "AC", CEPValid},
+		{"69999999", "AC", CEPValid},
+		{"88010-000", "", CEPValid},
+		{"88010-000", "SP", CEPUFMismatch},
+		{"1310100", "SC", CEPUFMismatch},
+		{"", "SC", CEPMissing},
+		{"-", "SC", CEPMissing},
+		{"00000-000", "SP", CEPInvalid},
+		{"1310-100", "SP", CEPInvalid},
+		// Padded to 08801-000, which is in São Paulo.
+		{"8801000", "SC", CEPUFMismatch},
+		{"880100000", "SC", CEPInvalid},
+	}
+	for _, tt := range tests {
+		if got := CheckCEP(tt.cep, tt.uf); got != tt.want {
+			t.Errorf("CheckCEP(%q, %q) = %q, want %q", tt.cep, tt.uf, got, tt.want)
+		}
+	}
+}
+
+func TestCEPUF(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"01000-000", "SP"},
+		{"19999-999", "SP"},
+		{"20000-000", "RJ"},
+		{"89999-999", "SC"},
+		{"00999-999", ""},
+		{"abc", ""},
+	}
+	for _, tt := range tests {
+		if got := CEPUF(tt.in); got != tt.want {
+			t.Errorf("CEPUF(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
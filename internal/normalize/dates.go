@@ -0,0 +1,87 @@
+package normalize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// Embed the zone database so America/Sao_Paulo resolves on minimal
+	// containers without /usr/share/zoneinfo.
+	_ "time/tzdata"
+)
+
+// DateFormat selects how Cadastur timestamps are rendered.
+type DateFormat string
+
+const (
+	// DateISO renders YYYY-MM-DD (default).
+	DateISO DateFormat = "iso"
+	// DateBR renders DD/MM/YYYY.
+	DateBR DateFormat = "br"
+	// DateRFC3339 renders the full timestamp with offset, e.g. 2026-10-16T00:00:00-03:00.
+	DateRFC3339 DateFormat = "rfc3339"
+	// DateEpochMs keeps the raw epoch milliseconds.
+	DateEpochMs DateFormat = "epoch"
+)
+
+// CadasturLocation is the zone Cadastur timestamps refer to (Brasília time),
+// so dates do not depend on the machine's local zone.
+var CadasturLocation = loadCadasturLocation()
+
+func loadCadasturLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		// Unreachable with time/tzdata embedded; keep a sane offset anyway.
+		return time.FixedZone("-03", -3*60*60)
+	}
+	return loc
+}
+
+// ParseDateFormat validates a user-supplied date format name.
+func ParseDateFormat(s string) (DateFormat, error) {
+	switch f := DateFormat(strings.ToLower(s)); f {
+	case DateISO, DateBR, DateRFC3339, DateEpochMs:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown date format %q (use iso, br, rfc3339 or epoch)", s)
+}
+
+// FormatMs renders a millisecond Unix timestamp in CadasturLocation using f.
+// Returns an empty string when ms == 0.
+func FormatMs(ms int64, f DateFormat) string {
+	if ms == 0 {
+		return ""
+	}
+	t := time.UnixMilli(ms).In(CadasturLocation)
+	switch f {
+	case DateBR:
+		return t.Format("02/01/2006")
+	case DateRFC3339:
+		return t.Format(time.RFC3339)
+	case DateEpochMs:
+		return strconv.FormatInt(ms, 10)
+	}
+	return t.Format("2006-01-02")
+}
+
+// ParseDate reads a date cell written in any DateFormat and returns it in
+// CadasturLocation. Date-only values are midnight of that day.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, CadasturLocation); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("02/01/2006", s, CadasturLocation); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(CadasturLocation), true
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil && ms != 0 {
+		return time.UnixMilli(ms).In(CadasturLocation), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,156 @@
+package normalize
+
+import "strings"
+
+// Document kinds reported by ParseDocument.
+const (
+	CPF  = "CPF"
+	CNPJ = "CNPJ"
+)
+
+// Document validation results reported by ParseDocument.
+const (
+	DocumentValid        = "valido"
+	DocumentMissing      = "ausente"
+	DocumentInvalid      = "invalido"
+	DocumentKindMismatch = "tipo-divergente"
+)
+
+// Document is a CPF or CNPJ found in a registration field.
+type Document struct {
+	// Kind is CPF, CNPJ or "" when the value has neither length.
+	Kind string
+	// Number holds the 11 (CPF) or 14 (CNPJ) characters without punctuation.
+	Number string
+	// Formatted is Number with the standard mask (000.000.000-00, 00.000.000/0000-00).
+	Formatted string
+	// Valid reports whether the check digits match.
+	Valid bool
+	// Status is one of the Document* validation results.
+	Status string
+}
+
+// ParseDocument classifies raw as a CPF or CNPJ, validates its check digits
+// and formats it. tipoPessoa ("PF"/"PJ", "Física"/"Jurídica") resolves
+// numbers whose leading zeros were lost and flags documents of the wrong
+// kind. Alphanumeric CNPJs (letters in the first 12 positions) are accepted.
+func ParseDocument(raw, tipoPessoa string) Document {
+	s := strings.ToUpper(strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+			return r
+		}
+		return -1
+	}, raw))
+	if s == "" {
+		return Document{Status: DocumentMissing}
+	}
+
+	want := PessoaKind(tipoPessoa)
+	kind := ""
+	switch {
+	case len(s) == 11 && isDigits(s) && want != CNPJ:
+		kind = CPF
+	case len(s) == 14:
+		kind = CNPJ
+	case len(s) < 11 && isDigits(s) && want == CPF:
+		s, kind = strings.Repeat("0", 11-len(s))+s, CPF
+	case len(s) < 14 && isDigits(s) && want == CNPJ:
+		s, kind = strings.Repeat("0", 14-len(s))+s, CNPJ
+	case len(s) == 11 && isDigits(s):
+		kind = CPF
+	}
+
+	d := Document{Kind: kind, Number: s, Status: DocumentInvalid}
+	switch kind {
+	case CPF:
+		d.Valid = validCPF(s)
+		d.Formatted = s[:3] + "." + s[3:6] + "." + s[6:9] + "-" + s[9:]
+	case CNPJ:
+		d.Valid = validCNPJ(s)
+		d.Formatted = s[:2] + "." + s[2:5] + "." + s[5:8] + "/" + s[8:12] + "-" + s[12:]
+	}
+	if d.Valid {
+		d.Status = DocumentValid
+		if want != "" && want != kind {
+			d.Status = DocumentKindMismatch
+		}
+	}
+	return d
+}
+
+// ProviderDocument parses a provider's CPF/CNPJ from its registroRf. An empty
+// registroRf is DocumentMissing: numeroCadastro is the Cadastur registration
+// number, not a CPF/CNPJ, so it is never used in its place.
+func ProviderDocument(registroRf, tipoPessoa string) Document {
+	return ParseDocument(registroRf, tipoPessoa)
+}
+
+// PessoaKind maps a TipoPessoa value to the document it should carry: CPF for
+// pessoa física, CNPJ for pessoa jurídica, "" when unknown.
+func PessoaKind(tipoPessoa string) string {
+	t := strings.ToUpper(strings.TrimSpace(FixMojibake(tipoPessoa)))
+	switch {
+	case t == "PF" || t == "F" || strings.Contains(t, "FÍS") || strings.Contains(t, "FIS"):
+		return CPF
+	case t == "PJ" || t == "J" || strings.Contains(t, "JUR"):
+		return CNPJ
+	}
+	return ""
+}
+
+// validCPF checks the two mod-11 check digits of an 11-digit CPF. Repeated
+// digits (000.000.000-00, 111.111.111-11...) pass the arithmetic but are invalid.
+func validCPF(s string) bool {
+	if strings.Count(s, s[:1]) == len(s) {
+		return false
+	}
+	return checkDigit(s[:9], 10) == s[9] && checkDigit(s[:10], 11) == s[10]
+}
+
+// validCNPJ checks the two mod-11 check digits of a CNPJ. Characters are
+// weighted by their ASCII code minus 48, which also covers alphanumeric CNPJs.
+func validCNPJ(s string) bool {
+	if !isDigits(s[12:]) || strings.Count(s, s[:1]) == len(s) {
+		return false
+	}
+	return cnpjDigit(s[:12]) == s[12] && cnpjDigit(s[:13]) == s[13]
+}
+
+// checkDigit computes a CPF check digit with weights from weight down to 2.
+func checkDigit(s string, weight int) byte {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum += int(s[i]-'0') * (weight - i)
+	}
+	return mod11(sum)
+}
+
+// cnpjDigit computes a CNPJ check digit with weights 2..9 cycling from the right.
+func cnpjDigit(s string) byte {
+	sum, weight := 0, 2
+	for i := len(s) - 1; i >= 0; i-- {
+		sum += int(s[i]-'0') * weight
+		if weight++; weight > 9 {
+			weight = 2
+		}
+	}
+	return mod11(sum)
+}
+
+func mod11(sum int) byte {
+	r := sum % 11
+	if r < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - r)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,75 @@
+package normalize
+
+import "testing"
+
+func TestParseDocument(t *testing.T) {
+	tests := []struct {
+		raw, tipo string
+		kind      string
+		formatted string
+		valid     bool
+		status    string
+	}{
+		{"529.982.247-25", "PF", CPF, "529.982.247-25", true, DocumentValid},
+		{"52998224725", "", CPF, "529.982.247-25", true, DocumentValid},
+		{"529.982.247-24", "PF", CPF, "529.982.247-24", false, DocumentInvalid},
+		{"111.111.111-11", "PF", CPF, "111.111.111-11", false, DocumentInvalid},
+		{"000.000.000-00", "", CPF, "000.000.000-00", false, DocumentInvalid},
+		// Leading zeros lost when the CPF went through a number.
+		{"1234567890", "Física", CPF, "012.345.678-90", true, DocumentValid},
+		{"11.222.333/0001-81", "PJ", CNPJ, "11.222.333/0001-81", true, DocumentValid},
+		{"11222333000181", "Jurídica", CNPJ, "11.222.333/0001-81", true, DocumentValid},
+		{"11.222.333/0001-80", "PJ", CNPJ, "11.222.333/0001-80", false, DocumentInvalid},
+		{"11111111111111", "PJ", CNPJ, "11.111.111/1111-11", false, DocumentInvalid},
+		{"1222333000181", "PJ", CNPJ, "01.222.333/0001-81", false, DocumentInvalid},
+		{"191000100012", "PJ", CNPJ, "00.191.000/1000-12", false, DocumentInvalid},
+		// Alphanumeric CNPJ (letters in the first 12 positions).
+		{"12.ABC.345/01DE-35", "PJ", CNPJ, "12.ABC.345/01DE-35", true, DocumentValid},
+		{"12.abc.345/01de-35", "PJ", CNPJ, "12.ABC.345/01DE-35", true, DocumentValid},
+		{"12.ABC.345/01DE-36", "PJ", CNPJ, "12.ABC.345/01DE-36", false, DocumentInvalid},
+		// Valid documents of the other kind.
+		{"529.982.247-25", "PJ", CNPJ, "00.052.998/2247-25", false, DocumentInvalid},
+		{"11.222.333/0001-81", "PF", CNPJ, "11.222.333/0001-81", true, DocumentKindMismatch},
+		{"", "PF", "", "", false, DocumentMissing},
+		{" -./ ", "", "", "", false, DocumentMissing},
+		{"12345", "", "", "", false, DocumentInvalid},
+	}
+	for _, tt := range tests {
+		d := ParseDocument(tt.raw, tt.tipo)
+		if d.Kind != tt.kind || d.Formatted != tt.formatted || d.Valid != tt.valid || d.Status != tt.status {
+			t.Errorf("ParseDocument(%q, %q) = {%q %q %v %q}, want {%q %q %v %q}",
+				tt.raw, tt.tipo, d.Kind, d.Formatted, d.Valid, d.Status, tt.kind, tt.formatted, tt.valid, tt.status)
+		}
+	}
+}
+
+func TestProviderDocument(t *testing.T) {
+	tests := []struct {
+		registroRf, tipo string
+		want, status     string
+	}{
+		{"11.222.333/0001-81", "PJ", "11222333000181", DocumentValid},
+		{"529.982.247-25", "PF", "52998224725", DocumentValid},
+		{"", "PF", "", DocumentMissing},
+		{"  ", "PJ", "", DocumentMissing},
+	}
+	for _, tt := range tests {
+		d := ProviderDocument(tt.registroRf, tt.tipo)
+		if d.Number != tt.want || d.Status != tt.status {
+			t.Errorf("ProviderDocument(%q, %q) = %q, %s, want %q, %s", tt.registroRf, tt.tipo, d.Number, d.Status, tt.want, tt.status)
+		}
+	}
+}
+
+func TestPessoaKind(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"PF", CPF}, {"f", CPF}, {"Física", CPF}, {"PESSOA FISICA", CPF}, {"FÃ­sica", CPF},
+		{"PJ", CNPJ}, {"J", CNPJ}, {"Jurídica", CNPJ}, {"pessoa juridica", CNPJ},
+		{"", ""}, {"outro", ""},
+	}
+	for _, tt := range tests {
+		if got := PessoaKind(tt.in); got != tt.want {
+			t.Errorf("PessoaKind(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package normalize
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// maxMojibakePasses bounds how many layers of mis-decoding are undone
+// (double and triple encoding happen when data goes through several systems).
+const maxMojibakePasses = 3
+
+// mojibakeRe matches sequences that UTF-8 text turns into when its bytes are
+// read as Windows-1252/Latin-1: a lead byte Ã/Â/Å/Æ/â followed by a
+// continuation byte rendered as a Latin-1 symbol or C1 control.
+var mojibakeRe = regexp.MustCompile(`[ÂÃÄÅÆâ][\x{0080}-\x{00BF}\x{0152}\x{0153}\x{0160}\x{0161}\x{0178}\x{017D}\x{017E}\x{0192}\x{02C6}\x{02DC}\x{2013}\x{2014}\x{2018}-\x{201E}\x{2020}-\x{2022}\x{2026}\x{2030}\x{2039}\x{203A}\x{20AC}\x{2122}]`)
+
+// cp1252Bytes maps the runes Windows-1252 assigns to bytes 0x80-0x9F back to
+// those bytes.
+var cp1252Bytes = func() map[rune]byte {
+	m := make(map[rune]byte)
+	for b := 0x80; b <= 0x9F; b++ {
+		if r := charmap.Windows1252.DecodeByte(byte(b)); r != utf8.RuneError {
+			m[r] = byte(b)
+		}
+	}
+	return m
+}()
+
+// HasMojibake reports whether s contains typical mojibake sequences.
+func HasMojibake(s string) bool {
+	return mojibakeRe.MatchString(s)
+}
+
+// RepairMojibake undoes UTF-8 text having been decoded as Windows-1252 or
+// Latin-1: the text is re-encoded to single bytes and those bytes are decoded
+// as UTF-8, up to maxMojibakePasses times. The result is only accepted when
+// every pass yields valid UTF-8 and the final text has no mojibake markers
+// left; otherwise s is returned unchanged. The bool reports whether s changed.
+func RepairMojibake(s string) (string, bool) {
+	if !HasMojibake(s) {
+		return s, false
+	}
+
+	cur := s
+	for pass := 0; pass < maxMojibakePasses && HasMojibake(cur); pass++ {
+		b, ok := toLatin1Bytes(cur)
+		if !ok || !utf8.Valid(b) {
+			return s, false
+		}
+		cur = string(b)
+	}
+
+	if HasMojibake(cur) {
+		return s, false
+	}
+	return cur, true
+}
+
+// toLatin1Bytes encodes s with one byte per rune, using Windows-1252 for
+// 0x80-0x9F symbols (€, “, ™...) and Latin-1 for the rest. It fails when a
+// rune has no single-byte form, which means s is not mis-decoded UTF-8.
+func toLatin1Bytes(s string) ([]byte, bool) {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x100:
+			out = append(out, byte(r))
+		case cp1252Bytes[r] != 0:
+			out = append(out, cp1252Bytes[r])
+		default:
+			return nil, false
+		}
+	}
+	return out, true
+}
@@ -0,0 +1,68 @@
+package normalize
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// garble decodes the UTF-8 bytes of s as Windows-1252, as a misconfigured
+// system would.
+func garble(s string) string {
+	out, err := charmap.Windows1252.NewDecoder().String(s)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func TestRepairMojibake(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		changed bool
+	}{
+		{"SÃ£o JosÃ©", "São José", true},
+		{"FLORIANÃ“POLIS", "FLORIANÓPOLIS", true},
+		{"Ã\u0081gua Boa", "Água Boa", true},
+		{garble("Conceição de Macabu"), "Conceição de Macabu", true},
+		{garble(garble("Guarda-Mor – Itaú")), "Guarda-Mor – Itaú", true},
+		{garble(garble(garble("Paraíso do Tocantins"))), "Paraíso do Tocantins", true},
+		{garble("Café ‘Pão de Açúcar’ €"), "Café ‘Pão de Açúcar’ €", true},
+		// Already correct text, including Ã and Â followed by plain letters.
+		{"São José", "São José", false},
+		{"AÇÃO SOCIAL", "AÇÃO SOCIAL", false},
+		{"ÂNGELA", "ÂNGELA", false},
+		{"", "", false},
+		// Correct and garbled text mixed cannot be repaired as a whole.
+		{"Conceição JosÃ©", "Conceição JosÃ©", false},
+		// A rune without a single-byte form means it was never mis-decoded.
+		{"JosÃ© ✓", "JosÃ© ✓", false},
+	}
+	for _, tt := range tests {
+		got, changed := RepairMojibake(tt.in)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("RepairMojibake(%q) = %q, %v, want %q, %v", tt.in, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestHasMojibake(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"SÃ£o JosÃ©", true},
+		{"â€“", true},
+		{"Conceição JosÃ©", true},
+		{"São José", false},
+		{"AÇÃO SOCIAL", false},
+		{"ÂNGELA", false},
+		{"Å", false},
+	}
+	for _, tt := range tests {
+		if got := HasMojibake(tt.in); got != tt.want {
+			t.Errorf("HasMojibake(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
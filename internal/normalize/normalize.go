@@ -3,11 +3,11 @@ package normalize
 import (
 	"fmt"
 	"strings"
-	"time"
 	"unicode"
-	"unicode/utf8"
 
-	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 // OnlyDigits returns a version of s that contains digits only.
@@ -22,14 +22,11 @@ func OnlyDigits(s string) string {
 	return b.String()
 }
 
-// MsToDate converts a millisecond Unix timestamp into YYYY-MM-DD.
+// MsToDate converts a millisecond Unix timestamp into YYYY-MM-DD,
+// as a calendar date in Cadastur's zone (see CadasturLocation).
 // Returns an empty string when ms == 0.
 func MsToDate(ms int64) string {
-	if ms == 0 {
-		return ""
-	}
-	t := time.Unix(0, ms*int64(time.Millisecond))
-	return t.Format("2006-01-02")
+	return FormatMs(ms, DateISO)
 }
 
 // Slugify turns a human-readable string into a safe filename fragment:
@@ -64,6 +61,17 @@ func Slugify(s string) string {
 	return string(clean)
 }
 
+// StripAccents removes diacritics from s ("Florianópolis" -> "Florianopolis").
+// Used where plain ASCII is preferred, such as file names built from column values.
+func StripAccents(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	out, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
 // EmptyIfNil safely dereferences optional string pointers for CSV output.
 func EmptyIfNil(s *string) string {
 	if s == nil {
@@ -81,60 +89,17 @@ func BoolToStr(b bool) string {
 }
 
 // IntPtrToStr renders optional integer pointers as strings ("" when nil).
-func IntPtrToStr(v *int) string {
+func IntPtrToStr[T ~int | ~int64](v *T) string {
 	if v == nil {
 		return ""
 	}
 	return fmt.Sprint(*v)
 }
 
-// FixMojibake attempts to fix common mojibake where UTF-8 bytes were
-// incorrectly interpreted as ISO-8859-1 (Latin1). If the string does
-// not appear garbled, it returns the original. Otherwise it decodes
-// the bytes as ISO-8859-1 into UTF-8.
+// FixMojibake repairs text whose UTF-8 bytes were decoded as
+// Windows-1252/ISO-8859-1 one or more times ("JosÃ©" -> "José").
+// Text that does not look garbled is returned unchanged; see RepairMojibake.
 func FixMojibake(s string) string {
-	if s == "" {
-		return s
-	}
-
-	// If string looks fine (valid UTF-8 and doesn't contain common mojibake markers), return early.
-	if utf8.ValidString(s) && !strings.ContainsAny(s, "ÃÂÃ") && !strings.Contains(s, "Ã") && !strings.Contains(s, "Â") {
-		return s
-	}
-
-	// Helper: score how many likely-correct accented chars exist
-	score := func(str string) int {
-		c := 0
-		for _, r := range str {
-			if r >= 0x80 && unicode.IsLetter(r) {
-				c++
-			}
-		}
-		return c
-	}
-
-	best := s
-	bestScore := score(s)
-
-	// Try Windows-1252
-	if b, err := charmap.Windows1252.NewDecoder().Bytes([]byte(s)); err == nil {
-		cand := string(b)
-		sc := score(cand)
-		if sc > bestScore {
-			best = cand
-			bestScore = sc
-		}
-	}
-
-	// Try ISO-8859-1
-	if b, err := charmap.ISO8859_1.NewDecoder().Bytes([]byte(s)); err == nil {
-		cand := string(b)
-		sc := score(cand)
-		if sc > bestScore {
-			best = cand
-			bestScore = sc
-		}
-	}
-
-	return best
+	fixed, _ := RepairMojibake(s)
+	return fixed
 }
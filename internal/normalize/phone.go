@@ -0,0 +1,165 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Phone kinds reported by ParsePhones.
+const (
+	PhoneMobile   = "movel"
+	PhoneLandline = "fixo"
+	// PhoneSpecial covers non-geographic numbers such as 0800 and 0300.
+	PhoneSpecial = "especial"
+)
+
+// Phone is one telephone number found in a raw NuTelefone value.
+type Phone struct {
+	Raw    string
+	DDD    string
+	Number string
+	Kind   string
+	E164   string
+	Valid  bool
+	// InferredDDD is set when the source omitted the area code and it was
+	// taken from a previous number in the field or from the provider's UF.
+	InferredDDD bool
+}
+
+// validDDDs lists every Brazilian area code (DDD) in use.
+var validDDDs = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true, "22": true, "24": true, "27": true, "28": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "37": true, "38": true,
+	"41": true, "42": true, "43": true, "44": true, "45": true, "46": true, "47": true, "48": true, "49": true,
+	"51": true, "53": true, "54": true, "55": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true, "66": true, "67": true, "68": true, "69": true,
+	"71": true, "73": true, "74": true, "75": true, "77": true, "79": true,
+	"81": true, "82": true, "83": true, "84": true, "85": true, "86": true, "87": true, "88": true, "89": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true, "97": true, "98": true, "99": true,
+}
+
+// capitalDDD is the area code of each UF's capital, used when a number has no DDD.
+var capitalDDD = map[string]string{
+	"AC": "68", "AL": "82", "AP": "96", "AM": "92", "BA": "71", "CE": "85", "DF": "61",
+	"ES": "27", "GO": "62", "MA": "98", "MT": "65", "MS": "67", "MG": "31", "PA": "91",
+	"PB": "83", "PR": "41", "PE": "81", "PI": "86", "RJ": "21", "RN": "84", "RS": "51",
+	"RO": "69", "RR": "95", "SC": "48", "SP": "11", "SE": "79", "TO": "63",
+}
+
+// phoneSepRe splits a field holding several numbers ("(48) 3333-4444 / 99999-8888").
+var phoneSepRe = regexp.MustCompile(`[/;,|\n]|\s+(?:e|ou)\s+`)
+
+// ValidDDD reports whether ddd is a Brazilian area code in use.
+func ValidDDD(ddd string) bool {
+	return validDDDs[ddd]
+}
+
+// ParsePhones splits a raw telephone field into individual numbers and
+// normalizes each one: the country code, trunk "0" and carrier prefixes are
+// stripped, the DDD is validated (or taken from uf when missing), the number
+// is classified as mobile or landline and rendered in E.164 (+55...).
+// A number without DDD reuses the DDD of the previous number in the same
+// field, falling back to the capital of uf.
+// Numbers that cannot be normalized are returned with Valid == false.
+func ParsePhones(raw, uf string) []Phone {
+	var phones []Phone
+	fallback := capitalDDD[strings.ToUpper(strings.TrimSpace(uf))]
+	for _, chunk := range phoneSepRe.Split(raw, -1) {
+		for _, part := range splitGlued(chunk) {
+			digits := OnlyDigits(part)
+			if digits == "" {
+				continue
+			}
+			p := parsePhone(strings.TrimSpace(part), digits, fallback)
+			if p.Valid && !p.InferredDDD && p.DDD != "" {
+				fallback = p.DDD
+			}
+			phones = append(phones, p)
+		}
+	}
+	return phones
+}
+
+// splitGlued separates two numbers written in one chunk with only spaces
+// between them ("4833334444 48999998888"), which would otherwise merge into
+// one long digit string.
+func splitGlued(chunk string) []string {
+	if len(OnlyDigits(chunk)) <= 13 {
+		return []string{chunk}
+	}
+	var parts []string
+	var cur strings.Builder
+	for _, f := range strings.Fields(chunk) {
+		cur.WriteString(f)
+		cur.WriteByte(' ')
+		if n := len(OnlyDigits(cur.String())); n >= 10 && n <= 13 {
+			parts = append(parts, cur.String())
+			cur.Reset()
+		}
+	}
+	if strings.TrimSpace(cur.String()) != "" {
+		parts = append(parts, cur.String())
+	}
+	return parts
+}
+
+// parsePhone normalizes one number; fallbackDDD is used when it has no area code.
+func parsePhone(raw, d, fallbackDDD string) Phone {
+	p := Phone{Raw: raw}
+
+	// Country code.
+	if strings.HasPrefix(d, "55") && (len(d) == 12 || len(d) == 13) {
+		d = d[2:]
+	}
+
+	// Non-geographic numbers (0800, 0300, ...): +55 800 ...
+	if len(d) >= 10 && d[0] == '0' && (d[1:4] == "800" || d[1:4] == "300" || d[1:4] == "500" || d[1:4] == "900") {
+		p.Number = d[1:]
+		p.Kind = PhoneSpecial
+		p.E164 = "+55" + p.Number
+		p.Valid = len(d) == 11
+		return p
+	}
+
+	// Trunk prefix "0", optionally followed by a two-digit carrier code:
+	// 0 + DDD + number (11-12 digits) or 0 + carrier + DDD + number (13-14 digits).
+	if d != "" && d[0] == '0' {
+		switch len(d) {
+		case 11, 12:
+			d = d[1:]
+		case 13, 14:
+			d = d[3:]
+		}
+	}
+
+	switch len(d) {
+	case 10, 11:
+		p.DDD, p.Number = d[:2], d[2:]
+	case 8, 9:
+		p.DDD, p.Number = fallbackDDD, d
+		p.InferredDDD = true
+	default:
+		return p
+	}
+	if !ValidDDD(p.DDD) {
+		return p
+	}
+
+	switch {
+	case len(p.Number) == 9 && p.Number[0] == '9':
+		p.Kind = PhoneMobile
+	case len(p.Number) == 8 && p.Number[0] >= '2' && p.Number[0] <= '5':
+		p.Kind = PhoneLandline
+	case len(p.Number) == 8 && p.Number[0] >= '6':
+		// Mobile number written without the ninth digit added in 2012-2016.
+		p.Number = "9" + p.Number
+		p.Kind = PhoneMobile
+	default:
+		return p
+	}
+
+	p.E164 = "+55" + p.DDD + p.Number
+	p.Valid = true
+	return p
+}
@@ -0,0 +1,55 @@
+package normalize
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParsePhones(t *testing.T) {
+	// want lists each number as E164/kind, a "*" marking an inferred DDD,
+	// or "invalid".
+	tests := []struct {
+		raw, uf string
+		want    string
+	}{
+		{"(48) 3333-4444", "SC", "+554833334444/fixo"},
+		{"(48) 99999-8888", "SC", "+5548999998888/movel"},
+		{"+55 (48) 99999-8888", "SC", "+5548999998888/movel"},
+		{"55 48 3333 4444", "", "+554833334444/fixo"},
+		{"048 3333-4444", "", "+554833334444/fixo"},
+		{"0 21 48 99999-8888", "", "+5548999998888/movel"},
+		{"3333-4444", "SC", "+554833334444/fixo*"},
+		{"3333-4444", "sc ", "+554833334444/fixo*"},
+		{"3333-4444", "", "invalid"},
+		{"(48) 8888-7777", "", "+5548988887777/movel"},
+		{"(47) 3333-4444 / 3222-1111", "SC", "+554733334444/fixo +554732221111/fixo*"},
+		{"(47) 3333-4444; (48) 99999-8888", "", "+554733334444/fixo +5548999998888/movel"},
+		{"(48) 3333-4444 ou 99999-8888", "", "+554833334444/fixo +5548999998888/movel*"},
+		{"4833334444 48999998888", "", "+554833334444/fixo +5548999998888/movel"},
+		{"0800 123 4567", "", "+558001234567/especial"},
+		{"0300 123 45678", "", "invalid"},
+		{"(23) 3333-4444", "", "invalid"},
+		{"(48) 1333-4444", "", "invalid"},
+		{"123", "SC", "invalid"},
+		{"", "SC", ""},
+		{"sem telefone", "SC", ""},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range ParsePhones(tt.raw, tt.uf) {
+			if !p.Valid {
+				got = append(got, "invalid")
+				continue
+			}
+			s := fmt.Sprintf("%s/%s", p.E164, p.Kind)
+			if p.InferredDDD {
+				s += "*"
+			}
+			got = append(got, s)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("ParsePhones(%q, %q) = %q, want %q", tt.raw, tt.uf, strings.Join(got, " "), tt.want)
+		}
+	}
+}
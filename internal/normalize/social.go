@@ -0,0 +1,196 @@
+package normalize
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// SocialNetworks lists the networks handled by ParseSocial, in column order.
+var SocialNetworks = []string{Instagram, Facebook, YouTube, TikTok, LinkedIn, WhatsApp}
+
+// SocialProfiles maps a social network to the canonical profile URL found for it.
+type SocialProfiles map[string]string
+
+var (
+	// socialURLRe finds profile URLs of known networks, with or without
+	// scheme. Group 1 is the character before the URL, which must not belong
+	// to a host name or an e-mail address (so "notfacebook.com" does not
+	// match); group 2 is the URL.
+	socialURLRe = regexp.MustCompile(`(?i)(^|[^a-z0-9.@_-])((?:https?://)?(?:[a-z0-9-]+\.)*(?:instagram\.com|instagr\.am|facebook\.com|fb\.com|fb\.me|youtube\.com|youtu\.be|tiktok\.com|linkedin\.com|wa\.me|whatsapp\.com)\b(?:/[^\s,;|]*)?)`)
+	// whatsappLabelRe finds "whats: (48) 99999-8888" style numbers.
+	whatsappLabelRe = regexp.MustCompile(`(?i)\b(?:whatsapp|whats|wpp|zap|wa)\b\s*[:=\-]?\s*(\+?[\d\s\-().]{8,}\d)`)
+	// labeledHandleRe finds "insta: xyz", "fb - xyz", "tiktok @xyz" style
+	// handles. The separator or @ is required, so the words after a bare
+	// label ("Facebook e Instagram", "no insta e no face") are not handles.
+	labeledHandleRe = regexp.MustCompile(`(?i)\b(instagram|insta|ig|facebook|face|fb|youtube|yt|tiktok|linkedin)\b\s*(?:[:=\-]\s*@?|@)([\w][\w.\-]*)`)
+	// bareHandleRe finds remaining "@handle" mentions.
+	bareHandleRe = regexp.MustCompile(`(?:^|[\s,;|(])@([\w][\w.]*)`)
+)
+
+// socialLabels maps the labels people write before a handle to a network.
+var socialLabels = map[string]string{
+	"instagram": Instagram, "insta": Instagram, "ig": Instagram,
+	"facebook": Facebook, "face": Facebook, "fb": Facebook,
+	"youtube": YouTube, "yt": YouTube,
+	"tiktok":   TikTok,
+	"linkedin": LinkedIn,
+}
+
+// ParseSocial extracts social-network profiles from a free-form field such
+// as AtividadeRedeSociais. It recognizes profile URLs, "insta: xyz" style
+// labels, WhatsApp numbers and bare @handles (taken as Instagram, the most
+// common case). Profiles are returned as canonical URLs; the first one found
+// per network wins. uf is used to complete WhatsApp numbers without DDD.
+func ParseSocial(raw, uf string) SocialProfiles {
+	out := SocialProfiles{}
+	s := FixMojibake(raw)
+
+	// 1) Full URLs; remove them so their paths are not re-read as handles.
+	s = socialURLRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := socialURLRe.FindStringSubmatch(m)
+		if network, profile := SocialProfileURL(sub[2]); profile != "" {
+			out.add(network, profile)
+		}
+		return sub[1] + " "
+	})
+
+	// 2) WhatsApp numbers after a label.
+	s = whatsappLabelRe.ReplaceAllStringFunc(s, func(m string) string {
+		number := whatsappLabelRe.FindStringSubmatch(m)[1]
+		for _, p := range ParsePhones(number, uf) {
+			if p.Valid {
+				out.add(WhatsApp, "https://wa.me/"+strings.TrimPrefix(p.E164, "+"))
+				break
+			}
+		}
+		return " "
+	})
+
+	// 3) Labeled handles.
+	s = labeledHandleRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := labeledHandleRe.FindStringSubmatch(m)
+		network := socialLabels[strings.ToLower(sub[1])]
+		out.add(network, profileFromHandle(network, sub[2]))
+		return " "
+	})
+
+	// 4) Bare @handles.
+	for _, sub := range bareHandleRe.FindAllStringSubmatch(s, -1) {
+		out.add(Instagram, profileFromHandle(Instagram, sub[1]))
+	}
+
+	return out
+}
+
+// add records a profile unless the network already has one.
+func (p SocialProfiles) add(network, profile string) {
+	if network == "" || profile == "" {
+		return
+	}
+	if _, ok := p[network]; !ok {
+		p[network] = profile
+	}
+}
+
+// nonProfilePaths are first path segments that name a post, a share link
+// or a site page rather than a profile, per network.
+var nonProfilePaths = map[string]map[string]bool{
+	Instagram: {"p": true, "reel": true, "reels": true, "tv": true, "stories": true, "explore": true, "accounts": true, "direct": true, "share": true},
+	TikTok:    {"t": true, "v": true, "tag": true, "music": true, "discover": true, "embed": true, "share": true},
+	Facebook:  {"share": true, "sharer": true, "sharer.php": true, "watch": true, "photo.php": true, "photo": true, "story.php": true, "permalink.php": true, "events": true, "hashtag": true},
+}
+
+// SocialProfileURL turns a social-network URL into its network and canonical
+// profile URL (https, bare domain, no tracking query or trailing slash).
+// Returns empty strings when the URL is not a recognizable profile.
+func SocialProfileURL(raw string) (network, profile string) {
+	w := NormalizeWebsite(raw)
+	if w.Network == "" {
+		return "", ""
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return "", ""
+	}
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if len(segments) > 0 && nonProfilePaths[w.Network][strings.ToLower(segments[0])] {
+		return "", ""
+	}
+
+	switch w.Network {
+	case Instagram, TikTok:
+		if len(segments) == 0 {
+			return "", ""
+		}
+		return w.Network, profileFromHandle(w.Network, segments[0])
+	case WhatsApp:
+		phone := u.Query().Get("phone")
+		if len(segments) > 0 && segments[0] != "send" {
+			phone = segments[0]
+		}
+		if d := OnlyDigits(phone); len(d) >= 10 {
+			if !strings.HasPrefix(d, "55") {
+				d = "55" + d
+			}
+			return WhatsApp, "https://wa.me/" + d
+		}
+		return "", ""
+	case Facebook:
+		if len(segments) == 0 {
+			return "", ""
+		}
+		if segments[0] == "profile.php" {
+			if id := u.Query().Get("id"); id != "" {
+				return Facebook, "https://facebook.com/profile.php?id=" + id
+			}
+			return "", ""
+		}
+		return Facebook, "https://facebook.com/" + strings.Join(segments, "/")
+	case YouTube:
+		// youtu.be/<id> and watch?v=<id> are videos; keep them playable.
+		if strings.HasSuffix(u.Hostname(), "youtu.be") {
+			if len(segments) == 0 {
+				return "", ""
+			}
+			return YouTube, "https://youtube.com/watch?v=" + url.QueryEscape(segments[0])
+		}
+		if len(segments) == 1 && segments[0] == "watch" {
+			if v := u.Query().Get("v"); v != "" {
+				return YouTube, "https://youtube.com/watch?v=" + url.QueryEscape(v)
+			}
+			return "", ""
+		}
+		if len(segments) == 0 {
+			return "", ""
+		}
+		return YouTube, "https://youtube.com/" + strings.Join(segments, "/")
+	case LinkedIn:
+		if len(segments) == 0 {
+			return "", ""
+		}
+		return LinkedIn, "https://linkedin.com/" + strings.Join(segments, "/")
+	}
+	return "", ""
+}
+
+// profileFromHandle builds the canonical profile URL for a handle.
+func profileFromHandle(network, handle string) string {
+	handle = strings.Trim(strings.TrimPrefix(strings.TrimSpace(handle), "@"), ".")
+	if handle == "" {
+		return ""
+	}
+	switch network {
+	case Instagram:
+		return "https://instagram.com/" + strings.ToLower(handle)
+	case TikTok:
+		return "https://tiktok.com/@" + strings.ToLower(handle)
+	case Facebook:
+		return "https://facebook.com/" + handle
+	case YouTube:
+		return "https://youtube.com/@" + handle
+	case LinkedIn:
+		return "https://linkedin.com/in/" + handle
+	}
+	return ""
+}
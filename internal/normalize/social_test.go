@@ -0,0 +1,75 @@
+package normalize
+
+import "testing"
+
+func TestSocialProfileURL(t *testing.T) {
+	tests := []struct {
+		raw, network, profile string
+	}{
+		{"instagram.com/Guia.SC/", Instagram, "https://instagram.com/guia.sc"},
+		{"https://www.instagram.com/guiasc?igshid=abc", Instagram, "https://instagram.com/guiasc"},
+		{"instagram.com/p/Cx1abc/", "", ""},
+		{"https://www.instagram.com/reel/abc", "", ""},
+		{"instagram.com/stories/guiasc/123", "", ""},
+		{"tiktok.com/@user/video/1", TikTok, "https://tiktok.com/@user"},
+		{"vm.tiktok.com/t/ZM123", "", ""},
+		{"https://youtu.be/dQw4w9WgXcQ?si=x", YouTube, "https://youtube.com/watch?v=dQw4w9WgXcQ"},
+		{"youtube.com/watch?v=abc&t=3", YouTube, "https://youtube.com/watch?v=abc"},
+		{"youtube.com/@canal", YouTube, "https://youtube.com/@canal"},
+		{"facebook.com/minhapagina", Facebook, "https://facebook.com/minhapagina"},
+		{"facebook.com/profile.php?id=123", Facebook, "https://facebook.com/profile.php?id=123"},
+		{"facebook.com/sharer.php?u=x", "", ""},
+		{"wa.me/5548999998888", WhatsApp, "https://wa.me/5548999998888"},
+		{"https://example.com.br", "", ""},
+	}
+	for _, tt := range tests {
+		network, profile := SocialProfileURL(tt.raw)
+		if network != tt.network || profile != tt.profile {
+			t.Errorf("SocialProfileURL(%q) = %q, %q; want %q, %q", tt.raw, network, profile, tt.network, tt.profile)
+		}
+	}
+}
+
+func TestParseSocial(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want SocialProfiles
+	}{
+		{"", SocialProfiles{}},
+		{"facebook.com/pagina, instagram.com/guia", SocialProfiles{
+			Facebook:  "https://facebook.com/pagina",
+			Instagram: "https://instagram.com/guia",
+		}},
+		{"https://www.instagram.com/guia;wa.me/5548999998888", SocialProfiles{
+			Instagram: "https://instagram.com/guia",
+			WhatsApp:  "https://wa.me/5548999998888",
+		}},
+		{"Insta: @Guia.SC | Whats (48) 99999-8888", SocialProfiles{
+			Instagram: "https://instagram.com/guia.sc",
+			WhatsApp:  "https://wa.me/5548999998888",
+		}},
+		{"@guiafloripa", SocialProfiles{Instagram: "https://instagram.com/guiafloripa"}},
+		{"Facebook e Instagram: @guia", SocialProfiles{Instagram: "https://instagram.com/guia"}},
+		{"Siga no insta e no face", SocialProfiles{}},
+		{"fb - guiasc, tiktok @guia.sc", SocialProfiles{
+			Facebook: "https://facebook.com/guiasc",
+			TikTok:   "https://tiktok.com/@guia.sc",
+		}},
+		// Hosts that only end in a network's domain are not that network.
+		{"site notfacebook.com/x", SocialProfiles{}},
+		{"facebook.community/x", SocialProfiles{}},
+		{"contato@facebook.com", SocialProfiles{}},
+	}
+	for _, tt := range tests {
+		got := ParseSocial(tt.raw, "SC")
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseSocial(%q) = %v, want %v", tt.raw, got, tt.want)
+			continue
+		}
+		for network, profile := range tt.want {
+			if got[network] != profile {
+				t.Errorf("ParseSocial(%q)[%s] = %q, want %q", tt.raw, network, got[network], profile)
+			}
+		}
+	}
+}
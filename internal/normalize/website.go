@@ -0,0 +1,131 @@
+package normalize
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Social networks recognized in website and social-media fields.
+const (
+	Instagram = "instagram"
+	Facebook  = "facebook"
+	YouTube   = "youtube"
+	TikTok    = "tiktok"
+	LinkedIn  = "linkedin"
+	WhatsApp  = "whatsapp"
+)
+
+// socialHosts maps registrable domains to their social network.
+var socialHosts = map[string]string{
+	"instagram.com":    Instagram,
+	"instagr.am":       Instagram,
+	"facebook.com":     Facebook,
+	"fb.com":           Facebook,
+	"fb.me":            Facebook,
+	"youtube.com":      YouTube,
+	"youtu.be":         YouTube,
+	"tiktok.com":       TikTok,
+	"linkedin.com":     LinkedIn,
+	"wa.me":            WhatsApp,
+	"whatsapp.com":     WhatsApp,
+	"api.whatsapp.com": WhatsApp,
+}
+
+// Website is a normalized NoWebSite value.
+type Website struct {
+	// URL is the normalized address (https://, lowercase host), or "" when empty.
+	URL string
+	// Valid reports whether URL has a well-formed domain.
+	Valid bool
+	// Network is set when the "website" is really a social-network profile.
+	Network string
+}
+
+var (
+	// schemeTypoRe matches broken schemes such as "http//", "https:/", "htps://".
+	schemeTypoRe = regexp.MustCompile(`^(?i)h?t+p+s?:?/+`)
+	// wwwTypoRe matches "ww.", "wwww." or "www," at the start of the host.
+	wwwTypoRe = regexp.MustCompile(`^(?i)w{2,4}[.,]`)
+	// hostLabelRe is one DNS label.
+	hostLabelRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+	// tldRe is a top-level domain (letters only, or an IDN "xn--" label).
+	tldRe = regexp.MustCompile(`^([a-z]{2,63}|xn--[a-z0-9-]+)$`)
+)
+
+// NormalizeWebsite cleans a website value: removes spaces, fixes scheme and
+// "www" typos, adds https:// when missing, lowercases the host and validates
+// the domain syntax. E-mail addresses are returned unchanged and invalid. Social-network URLs and @handles are reported through
+// Network so callers can move them to the right social column.
+func NormalizeWebsite(raw string) Website {
+	s := strings.Join(strings.Fields(raw), "")
+	if s == "" {
+		return Website{}
+	}
+
+	// A bare "@handle" is an Instagram profile in practice.
+	if strings.HasPrefix(s, "@") && len(s) > 1 && !strings.ContainsAny(s, "/.") {
+		return Website{URL: "https://instagram.com/" + strings.ToLower(s[1:]), Valid: true, Network: Instagram}
+	}
+
+	// Drop any (possibly misspelled) scheme; everything is served over https now.
+	if loc := schemeTypoRe.FindStringIndex(s); loc != nil {
+		s = s[loc[1]:]
+	}
+	s = strings.TrimLeft(s, "/")
+	if loc := wwwTypoRe.FindStringIndex(s); loc != nil {
+		s = "www." + s[loc[1]:]
+	}
+
+	u, err := url.Parse("https://" + s)
+	if err != nil || u.Host == "" {
+		return Website{URL: "https://" + s}
+	}
+	// "contato@exemplo.com" is an e-mail address, not a site: keep it as
+	// written, without a scheme, and report it invalid.
+	if u.User != nil {
+		return Website{URL: s}
+	}
+	u.Host = strings.TrimSuffix(strings.ToLower(u.Host), ".")
+	if u.Path == "/" && u.RawQuery == "" && u.Fragment == "" {
+		u.Path = ""
+	}
+
+	w := Website{URL: u.String(), Valid: validHost(u.Hostname())}
+	w.Network = socialNetwork(u.Hostname())
+	return w
+}
+
+// validHost checks the syntax of a domain name: at least two labels, each a
+// valid DNS label, and an alphabetic TLD.
+func validHost(host string) bool {
+	if len(host) > 253 {
+		return false
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if len(l) > 63 || !hostLabelRe.MatchString(l) {
+			return false
+		}
+	}
+	return tldRe.MatchString(labels[len(labels)-1])
+}
+
+// socialNetwork returns the social network serving host ("" for ordinary sites).
+func socialNetwork(host string) string {
+	host = strings.TrimPrefix(host, "www.")
+	host = strings.TrimPrefix(host, "m.")
+	if n, ok := socialHosts[host]; ok {
+		return n
+	}
+	// Subdomains such as "pt-br.facebook.com" or "br.linkedin.com".
+	for domain, n := range socialHosts {
+		if strings.HasSuffix(host, "."+domain) {
+			return n
+		}
+	}
+	return ""
+}
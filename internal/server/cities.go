@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/normalize"
+)
+
+// maxCitySuggestions bounds a /cities answer.
+const maxCitySuggestions = 20
+
+// cityIndex remembers the municipalities seen in exports, per UF, to suggest
+// values for the cidade filter. The API has no city list, so suggestions
+// grow as exports run; they are kept next to the domain cache
+// (cidades-<uf>.json) so they survive restarts.
+type cityIndex struct {
+	dir   string
+	mu    sync.Mutex
+	byUF  map[int]map[string]bool
+	dirty map[int]bool
+}
+
+func newCityIndex(dir string) *cityIndex {
+	return &cityIndex{dir: dir, byUF: map[int]map[string]bool{}, dirty: map[int]bool{}}
+}
+
+func (c *cityIndex) path(uf int) string {
+	return filepath.Join(c.dir, fmt.Sprintf("cidades-%d.json", uf))
+}
+
+// load returns the cities of uf, reading the file on first use. c.mu must be held.
+func (c *cityIndex) load(uf int) map[string]bool {
+	if cities, ok := c.byUF[uf]; ok {
+		return cities
+	}
+	cities := map[string]bool{}
+	if c.dir != "" {
+		var names []string
+		if b, err := os.ReadFile(c.path(uf)); err == nil && json.Unmarshal(b, &names) == nil {
+			for _, n := range names {
+				cities[n] = true
+			}
+		}
+	}
+	c.byUF[uf] = cities
+	return cities
+}
+
+// learn adds the municipalities of a page of providers.
+func (c *cityIndex) learn(uf int, list []cadastur.Prestador) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cities := c.load(uf)
+	for _, p := range list {
+		name := normalize.CollapseSpaces(normalize.FixMojibake(string(p.Municipio)))
+		if name != "" && !cities[name] {
+			cities[name] = true
+			c.dirty[uf] = true
+		}
+	}
+}
+
+// save writes the cities of uf if new ones were learned.
+func (c *cityIndex) save(uf int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty[uf] || c.dir == "" {
+		return
+	}
+	names := sortedKeys(c.byUF[uf])
+	b, err := json.Marshal(names)
+	if err == nil {
+		err = os.MkdirAll(c.dir, 0o755)
+	}
+	if err == nil {
+		tmp := c.path(uf) + ".tmp"
+		if err = os.WriteFile(tmp, b, 0o644); err == nil {
+			err = os.Rename(tmp, c.path(uf))
+		}
+	}
+	if err != nil {
+		slog.Warn("failed to save city suggestions", "uf", uf, "err", err)
+		return
+	}
+	c.dirty[uf] = false
+}
+
+// list returns the cities of uf containing q, ignoring case and accents.
+func (c *cityIndex) list(uf int, q string) []string {
+	c.mu.Lock()
+	names := sortedKeys(c.load(uf))
+	c.mu.Unlock()
+
+	q = fold(q)
+	out := []string{}
+	for _, n := range names {
+		if strings.Contains(fold(n), q) {
+			out = append(out, n)
+			if len(out) == maxCitySuggestions {
+				break
+			}
+		}
+	}
+	return out
+}
+
+func fold(s string) string {
+	return strings.ToLower(normalize.StripAccents(strings.TrimSpace(s)))
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
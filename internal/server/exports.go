@@ -0,0 +1,311 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/csvx"
+)
+
+const (
+	// maxRunningExports bounds the exports running at once; they share the
+	// upstream rate limit, so more would only be slower.
+	maxRunningExports = 4
+	// exportTTL is how long a finished export stays available for download.
+	exportTTL = time.Hour
+	// purgeInterval is how often expired exports are removed when no request
+	// comes in to do it.
+	purgeInterval = 5 * time.Minute
+)
+
+// Export states reported by GET /exports/{id}.
+const (
+	exportRunning  = "running"
+	exportDone     = "done"
+	exportFailed   = "failed"
+	exportCanceled = "canceled"
+)
+
+// exportStatus is the progress of an export started with POST /exports.
+type exportStatus struct {
+	ID         string    `json:"id"`
+	State      string    `json:"state"`
+	FileName   string    `json:"fileName"`
+	Rows       int       `json:"rows"`
+	Pages      int       `json:"pages"`
+	Total      int       `json:"total"`
+	Error      string    `json:"error,omitempty"`
+	Download   string    `json:"download,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+}
+
+// exportJob is an export written to a file in the background, so the web
+// page can poll its progress and download the result.
+type exportJob struct {
+	mu     sync.Mutex
+	status exportStatus
+	path   string
+	format csvx.Format
+	cancel context.CancelFunc
+}
+
+func (j *exportJob) snapshot() exportStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// exportStore keeps the background exports and their files.
+type exportStore struct {
+	dir    string
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*exportJob
+}
+
+// newExportStore returns a store that removes expired exports every
+// purgeInterval until Close.
+func newExportStore(dir string) *exportStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &exportStore{dir: dir, ctx: ctx, cancel: cancel, jobs: map[string]*exportJob{}}
+	st.wg.Add(1)
+	go func() {
+		defer st.wg.Done()
+		t := time.NewTicker(purgeInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				st.mu.Lock()
+				st.purge()
+				st.mu.Unlock()
+			}
+		}
+	}()
+	return st
+}
+
+// get returns a job that has not expired.
+func (st *exportStore) get(id string) (*exportJob, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.purge()
+	j, ok := st.jobs[id]
+	return j, ok
+}
+
+// purge removes the jobs finished more than exportTTL ago and their files.
+// The caller holds st.mu.
+func (st *exportStore) purge() {
+	for id, j := range st.jobs {
+		s := j.snapshot()
+		if s.State != exportRunning && time.Since(s.FinishedAt) > exportTTL {
+			os.Remove(j.path)
+			delete(st.jobs, id)
+		}
+	}
+}
+
+// remove cancels a running job, which then removes its partial file, or
+// deletes a finished job and its file. It reports whether id was known.
+func (st *exportStore) remove(id string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	j, ok := st.jobs[id]
+	if !ok {
+		return false
+	}
+	// Holding j.mu keeps runExport from finishing in between.
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status.State == exportRunning {
+		j.cancel()
+		return true
+	}
+	os.Remove(j.path)
+	delete(st.jobs, id)
+	return true
+}
+
+// add registers a new running job, after dropping the expired ones.
+func (st *exportStore) add(fileName string, format csvx.Format) (*exportJob, context.Context, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.purge()
+	running := 0
+	for _, j := range st.jobs {
+		if j.snapshot().State == exportRunning {
+			running++
+		}
+	}
+	if running >= maxRunningExports {
+		return nil, nil, errTooManyExports
+	}
+
+	b := make([]byte, 8)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	ctx, cancel := context.WithCancel(st.ctx)
+	j := &exportJob{
+		status: exportStatus{ID: id, State: exportRunning, FileName: fileName, Total: -1, StartedAt: time.Now()},
+		path:   filepath.Join(st.dir, id+"."+string(format)),
+		format: format,
+		cancel: cancel,
+	}
+	st.jobs[id] = j
+	st.wg.Add(1)
+	return j, ctx, nil
+}
+
+var errTooManyExports = errors.New("too many exports running, try again later")
+
+// Close cancels the running exports and stops the purge of expired ones,
+// waiting for both.
+func (s *Server) Close() {
+	s.exports.cancel()
+	s.exports.wg.Wait()
+}
+
+// handleStartExport starts a background export from the web page form
+// (same parameters as /prestadores) and answers 202 with its status.
+func (s *Server) handleStartExport(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	req, status, err := s.parseExport(r.Context(), r.Form)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	if err := os.MkdirAll(s.exports.dir, 0o755); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to create export dir: %w", err))
+		return
+	}
+	job, ctx, err := s.exports.add(exportName(req), req.opts.Format)
+	if err != nil {
+		writeError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	go s.runExport(ctx, job, req)
+
+	w.Header().Set("Location", "/exports/"+job.status.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, job.snapshot())
+}
+
+// runExport writes a background export to its file, updating the job status
+// after every page.
+func (s *Server) runExport(ctx context.Context, job *exportJob, req export) {
+	defer s.exports.wg.Done()
+	defer job.cancel()
+
+	err := func() error {
+		f, err := os.Create(job.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out, err := csvx.NewStream(f, req.opts)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if err := out.WriteHeader(); err != nil {
+			return err
+		}
+		err = s.fetch(ctx, req, func(list []cadastur.Prestador, total int) error {
+			for _, p := range list {
+				if err := out.WriteRow(p); err != nil {
+					return err
+				}
+			}
+			job.mu.Lock()
+			job.status.Rows += len(list)
+			job.status.Pages++
+			job.status.Total = total
+			job.mu.Unlock()
+			return out.Flush()
+		})
+		if err != nil {
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return f.Close()
+	}()
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	job.status.FinishedAt = time.Now()
+	attrs := []any{"id", job.status.ID, "uf", req.uf.SgUf, "activity", req.activity.NuAtividadeTuristica, "city", req.city, "rows", job.status.Rows, "pages", job.status.Pages}
+	switch {
+	case err == nil:
+		job.status.State = exportDone
+		job.status.Download = "/exports/" + job.status.ID + "/download"
+		slog.Info("export finished", attrs...)
+	case ctx.Err() != nil:
+		job.status.State = exportCanceled
+		os.Remove(job.path)
+		slog.Info("export canceled", attrs...)
+	default:
+		job.status.State, job.status.Error = exportFailed, err.Error()
+		os.Remove(job.path)
+		slog.Error("export failed", append(attrs, "err", err)...)
+	}
+}
+
+func (s *Server) handleExportStatus(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.exports.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("unknown export"))
+		return
+	}
+	writeJSON(w, job.snapshot())
+}
+
+func (s *Server) handleExportDownload(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.exports.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("unknown export"))
+		return
+	}
+	status := job.snapshot()
+	if status.State != exportDone {
+		writeError(w, http.StatusConflict, fmt.Errorf("export is %s", status.State))
+		return
+	}
+	w.Header().Set("Content-Type", contentType(job.format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": status.FileName}))
+	http.ServeFile(w, r, job.path)
+}
+
+// handleCancelExport stops a running export, whose partial file is removed,
+// or deletes a finished export and its file.
+func (s *Server) handleCancelExport(w http.ResponseWriter, r *http.Request) {
+	if !s.exports.remove(r.PathValue("id")) {
+		writeError(w, http.StatusNotFound, errors.New("unknown export"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,383 @@
+// Package server exposes the Cadastur exports over HTTP: the UF and activity
+// lists, and provider exports streamed page by page as CSV, JSON or NDJSON.
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/csvx"
+	"cadastur-csv/internal/normalize"
+)
+
+// openAPI describes the endpoints; it is served at /openapi.yaml.
+//
+//go:embed openapi.yaml
+var openAPI []byte
+
+// Config holds the settings shared by every request.
+type Config struct {
+	// PageSize is the number of providers per upstream request.
+	PageSize int
+	// Privacy, when set, redacts pessoa física rows in every export (LGPD profile).
+	Privacy *csvx.Privacy
+	// ExportDir holds the files of the exports started from the web page.
+	ExportDir string
+}
+
+// Server handles the HTTP API and the web page. Upstream calls go through
+// one Service, so its rate limit applies to all requests together, and the
+// domain lists come from one Domains cache.
+type Server struct {
+	service *cadastur.Service
+	domains *cadastur.Domains
+	cfg     Config
+	mux     *http.ServeMux
+	cities  *cityIndex
+	exports *exportStore
+}
+
+// New creates a Server. Close cancels the exports still running.
+func New(service *cadastur.Service, domains *cadastur.Domains, cfg Config) *Server {
+	s := &Server{
+		service: service,
+		domains: domains,
+		cfg:     cfg,
+		mux:     http.NewServeMux(),
+		cities:  newCityIndex(domains.Dir),
+		exports: newExportStore(cfg.ExportDir),
+	}
+	s.mux.HandleFunc("GET /ufs", s.handleUFs)
+	s.mux.HandleFunc("GET /activities", s.handleActivities)
+	s.mux.HandleFunc("GET /prestadores", s.handlePrestadores)
+	s.mux.HandleFunc("GET /openapi.yaml", s.handleOpenAPI)
+	s.mux.HandleFunc("GET /columns", s.handleColumns)
+	s.mux.HandleFunc("GET /cities", s.handleCities)
+	s.mux.HandleFunc("POST /exports", s.handleStartExport)
+	s.mux.HandleFunc("GET /exports/{id}", s.handleExportStatus)
+	s.mux.HandleFunc("GET /exports/{id}/download", s.handleExportDownload)
+	s.mux.HandleFunc("DELETE /exports/{id}", s.handleCancelExport)
+	s.mux.Handle("GET /", http.FileServerFS(webFS))
+	return s
+}
+
+// ServeHTTP logs every request and routes it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	slog.InfoContext(r.Context(), "request", "method", r.Method, "path", r.URL.Path, "query", r.URL.RawQuery, "status", rec.status, "duration", time.Since(start))
+}
+
+// handleUFs lists the UFs. X-Cadastur-Source tells whether the list came
+// from the API, the cache or the embedded snapshot.
+func (s *Server) handleUFs(w http.ResponseWriter, r *http.Request) {
+	ufs, source, err := s.domains.UFs(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch UFs: %w", err))
+		return
+	}
+	w.Header().Set("X-Cadastur-Source", string(source))
+	writeJSON(w, ufs)
+}
+
+// handleActivities lists the active tourism activities, or all of them with ?all=true.
+func (s *Server) handleActivities(w http.ResponseWriter, r *http.Request) {
+	acts, source, err := s.domains.Activities(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch activities: %w", err))
+		return
+	}
+	if all, _ := strconv.ParseBool(r.URL.Query().Get("all")); !all {
+		active := acts[:0:0]
+		for _, a := range acts {
+			if a.FlAtivo {
+				active = append(active, a)
+			}
+		}
+		acts = active
+	}
+	w.Header().Set("X-Cadastur-Source", string(source))
+	writeJSON(w, acts)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPI)
+}
+
+// export is a validated /prestadores request.
+type export struct {
+	uf       cadastur.UF
+	activity cadastur.Activity
+	city     string
+	opts     csvx.Options
+}
+
+// handlePrestadores streams an export: each upstream page is written and
+// flushed before the next one is requested, and a canceled request stops
+// the paging. Errors before the first page get a JSON error response; after
+// that the status is already sent, so the connection is aborted instead and
+// the client sees a truncated download rather than a complete-looking file.
+func (s *Server) handlePrestadores(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, status, err := s.parseExport(ctx, r.URL.Query())
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	warnings := 0
+	ctx = cadastur.WithWarningHandler(ctx, func(cadastur.Warning) { warnings++ })
+	rc := http.NewResponseController(w)
+
+	var out csvx.RecordWriter
+	rows, pages := 0, 0
+	err = s.fetch(ctx, req, func(list []cadastur.Prestador, total int) error {
+		if out == nil {
+			h := w.Header()
+			h.Set("Content-Type", contentType(req.opts.Format))
+			h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": exportName(req)}))
+			h.Set("X-Total-Results", strconv.Itoa(total))
+			var err error
+			if out, err = csvx.NewStream(w, req.opts); err != nil {
+				return err
+			}
+			if err := out.WriteHeader(); err != nil {
+				return err
+			}
+		}
+		for _, p := range list {
+			if err := out.WriteRow(p); err != nil {
+				return err
+			}
+		}
+		rows += len(list)
+		pages++
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		return rc.Flush()
+	})
+	if err == nil {
+		err = out.Close()
+	}
+
+	attrs := []any{"uf", req.uf.SgUf, "activity", req.activity.NuAtividadeTuristica, "city", req.city, "format", req.opts.Format, "rows", rows, "pages", pages, "warnings", warnings}
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "export streamed", attrs...)
+	case ctx.Err() != nil:
+		slog.InfoContext(ctx, "export canceled by client", attrs...)
+	case out == nil:
+		slog.ErrorContext(ctx, "export failed", append(attrs, "err", err)...)
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch prestadores: %w", err))
+	default:
+		slog.ErrorContext(ctx, "export aborted", append(attrs, "err", err)...)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// fetch pages through the providers selected by req, and learns the cities
+// they are in for the city suggestions.
+func (s *Server) fetch(ctx context.Context, req export, onPage func(list []cadastur.Prestador, total int) error) error {
+	filters := cadastur.BuildFilters(int(req.uf.ID), string(req.activity.NoAtividadeTuristica), req.city)
+	err := s.service.FetchPrestadoresPaged(ctx, filters, s.cfg.PageSize, func(list []cadastur.Prestador, _ int, total int) error {
+		s.cities.learn(int(req.uf.ID), list)
+		return onPage(list, total)
+	})
+	s.cities.save(int(req.uf.ID))
+	return err
+}
+
+// parseExport validates the parameters of an export (the /prestadores query
+// or the /exports form), returning the HTTP status to answer with when they
+// are invalid.
+func (s *Server) parseExport(ctx context.Context, q url.Values) (export, int, error) {
+	var req export
+
+	ufs, _, err := s.domains.UFs(ctx)
+	if err != nil {
+		return req, http.StatusBadGateway, fmt.Errorf("failed to fetch UFs: %w", err)
+	}
+	uf, ok := findUF(ufs, q.Get("uf"))
+	if !ok {
+		return req, http.StatusBadRequest, fmt.Errorf("unknown or missing uf %q (use the ID or the abbreviation, see /ufs)", q.Get("uf"))
+	}
+	req.uf = uf
+
+	acts, _, err := s.domains.Activities(ctx)
+	if err != nil {
+		return req, http.StatusBadGateway, fmt.Errorf("failed to fetch activities: %w", err)
+	}
+	act, ok := findActivity(acts, q.Get("atividade"))
+	if !ok {
+		return req, http.StatusBadRequest, fmt.Errorf("unknown or missing atividade %q (see /activities)", q.Get("atividade"))
+	}
+	req.activity = act
+	req.city = strings.TrimSpace(q.Get("cidade"))
+
+	req.opts.Format = csvx.FormatCSV
+	if v := q.Get("format"); v != "" {
+		switch f := csvx.Format(strings.ToLower(v)); f {
+		case csvx.FormatCSV, csvx.FormatJSON, csvx.FormatNDJSON:
+			req.opts.Format = f
+		default:
+			return req, http.StatusBadRequest, fmt.Errorf("unknown format %q (use csv, json or ndjson)", v)
+		}
+	}
+
+	columns, err := exportColumns(q.Get("colunas"), q.Get("extra"))
+	if err != nil {
+		return req, http.StatusBadRequest, err
+	}
+	if s.cfg.Privacy != nil {
+		columns = csvx.WithPrivacy(columns, *s.cfg.Privacy)
+	}
+	req.opts.Columns = columns
+	return req, 0, nil
+}
+
+// exportColumns picks the columns of an export: the ones named in colunas,
+// in that order, or else the default columns followed by the extra groups.
+func exportColumns(colunas, extra string) ([]csvx.Column, error) {
+	names := splitList(colunas)
+	if len(names) == 0 {
+		return csvx.ColumnsWith(splitList(extra))
+	}
+	columns := make([]csvx.Column, 0, len(names))
+	for _, name := range names {
+		c, ok := csvx.ColumnByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (see /columns)", name)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// handleColumns lists the default columns and the extra column groups, for
+// the column picker.
+func (s *Server) handleColumns(w http.ResponseWriter, r *http.Request) {
+	groups := map[string][]string{}
+	for name, columns := range csvx.ExtraColumns {
+		groups[name] = csvx.ColumnNames(columns)
+	}
+	writeJSON(w, map[string]any{
+		"default": csvx.ColumnNames(csvx.DefaultColumns),
+		"groups":  groups,
+	})
+}
+
+// handleCities suggests cities of a UF for the cidade filter.
+func (s *Server) handleCities(w http.ResponseWriter, r *http.Request) {
+	ufs, _, err := s.domains.UFs(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch UFs: %w", err))
+		return
+	}
+	uf, ok := findUF(ufs, r.URL.Query().Get("uf"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown or missing uf %q", r.URL.Query().Get("uf")))
+		return
+	}
+	writeJSON(w, s.cities.list(int(uf.ID), r.URL.Query().Get("q")))
+}
+
+// findUF matches a UF by ID or abbreviation (case-insensitive).
+func findUF(ufs []cadastur.UF, v string) (cadastur.UF, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return cadastur.UF{}, false
+	}
+	id, _ := strconv.Atoi(v)
+	for _, uf := range ufs {
+		if (id > 0 && int(uf.ID) == id) || strings.EqualFold(string(uf.SgUf), v) {
+			return uf, true
+		}
+	}
+	return cadastur.UF{}, false
+}
+
+// findActivity matches an activity by ID.
+func findActivity(acts []cadastur.Activity, v string) (cadastur.Activity, bool) {
+	id, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return cadastur.Activity{}, false
+	}
+	for _, a := range acts {
+		if int(a.NuAtividadeTuristica) == id {
+			return a, true
+		}
+	}
+	return cadastur.Activity{}, false
+}
+
+// exportName suggests a download name: "prestadores-sc-guia-de-turismo.csv".
+func exportName(req export) string {
+	parts := []string{"prestadores", strings.ToLower(string(req.uf.SgUf)), normalize.Slugify(string(req.activity.NoAtividadeTuristica))}
+	if req.city != "" {
+		parts = append(parts, normalize.Slugify(req.city))
+	}
+	return strings.Join(parts, "-") + "." + string(req.opts.Format)
+}
+
+func contentType(f csvx.Format) string {
+	switch f {
+	case csvx.FormatJSON:
+		return "application/json"
+	case csvx.FormatNDJSON:
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("failed to write response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// splitList splits a comma-separated query value, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// statusRecorder keeps the response status for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer to flush.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
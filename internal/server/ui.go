@@ -0,0 +1,13 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// web holds the page served at / for building and downloading exports.
+//
+//go:embed web
+var web embed.FS
+
+var webFS, _ = fs.Sub(web, "web")
@@ -0,0 +1,200 @@
+// Export builder for `cadastur-csv serve`: fills the filters from the cached
+// domain lists, starts a background export (POST /exports), polls its
+// progress and shows the download link.
+"use strict";
+
+const $ = (id) => document.getElementById(id);
+const fold = (s) => s.normalize("NFD").replace(/[\u0300-\u036f]/g, "").toLowerCase().trim();
+const number = new Intl.NumberFormat("pt-BR");
+
+let ufs = [];
+let defaultColumns = [];
+let current = null;
+
+async function getJSON(url) {
+  const resp = await fetch(url);
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return { body, source: resp.headers.get("X-Cadastur-Source") };
+}
+
+// searchable binds a search box to a <select>, showing only the options
+// whose label contains the typed text (ignoring case and accents).
+function searchable(input, select, items, selected) {
+  const render = () => {
+    const q = fold(input.value);
+    const keep = select.value || String(selected);
+    select.replaceChildren();
+    for (const item of items) {
+      if (q && !fold(item.label).includes(q)) {
+        continue;
+      }
+      const opt = new Option(item.label, item.value);
+      opt.selected = String(item.value) === keep;
+      select.add(opt);
+    }
+    if (select.selectedIndex < 0 && select.options.length > 0) {
+      select.selectedIndex = 0;
+    }
+    select.dispatchEvent(new Event("change"));
+  };
+  input.addEventListener("input", render);
+  render();
+}
+
+function noteSources(sources) {
+  const stale = sources.filter(([, s]) => s && s !== "api" && s !== "cache");
+  $("sources").textContent = stale.length
+    ? "API indisponível: " + stale.map(([name, s]) => `${name} do ${s}`).join(", ") + "; as listas podem estar desatualizadas."
+    : "";
+}
+
+function renderColumns(columns) {
+  defaultColumns = columns.default;
+  const box = $("columns");
+  const group = (title, names, checked) => {
+    const h = document.createElement("h3");
+    h.textContent = title;
+    box.append(h);
+    for (const name of names) {
+      const label = document.createElement("label");
+      const input = document.createElement("input");
+      input.type = "checkbox";
+      input.value = name;
+      input.checked = checked;
+      label.append(input, " " + name);
+      box.append(label);
+    }
+  };
+  group("Padrão", columns.default, true);
+  for (const name of Object.keys(columns.groups).sort()) {
+    group("Grupo " + name, columns.groups[name], false);
+  }
+}
+
+function setColumns(pick) {
+  for (const input of $("columns").querySelectorAll("input")) {
+    input.checked = pick(input.value);
+  }
+}
+
+let cityTimer = 0;
+function suggestCities() {
+  clearTimeout(cityTimer);
+  cityTimer = setTimeout(async () => {
+    const uf = ufs.find((u) => String(u.id) === $("uf").value);
+    if (!uf) {
+      return;
+    }
+    const q = $("city").value.split(",")[0];
+    try {
+      const { body } = await getJSON(`cities?uf=${uf.id}&q=${encodeURIComponent(q)}`);
+      $("cities").replaceChildren(...body.map((name) => new Option(`${name}, ${uf.sgUf}`)));
+    } catch {
+      // Suggestions are optional.
+    }
+  }, 200);
+}
+
+function showStatus(s) {
+  const bar = $("bar");
+  if (s.total >= 0) {
+    bar.max = Math.max(s.total, 1);
+    bar.value = s.state === "done" ? bar.max : s.rows;
+  }
+  const total = s.total >= 0 ? ` de ${number.format(s.total)}` : "";
+  const status = $("status");
+  status.classList.toggle("error", s.state === "failed");
+  switch (s.state) {
+    case "running":
+      status.textContent = `Baixando… ${number.format(s.rows)}${total} linhas (${s.pages} páginas)`;
+      break;
+    case "done":
+      status.textContent = `Concluído: ${number.format(s.rows)} linhas em ${s.pages} páginas.`;
+      break;
+    case "canceled":
+      status.textContent = "Exportação cancelada.";
+      break;
+    default:
+      status.textContent = "Falhou: " + s.error;
+  }
+  const finished = s.state !== "running";
+  $("cancel").hidden = finished;
+  $("start").disabled = !finished;
+  const link = $("download");
+  link.hidden = s.state !== "done";
+  if (s.state === "done") {
+    link.href = s.download;
+    link.download = s.fileName;
+    link.textContent = "Baixar " + s.fileName;
+  }
+}
+
+async function poll() {
+  if (!current) {
+    return;
+  }
+  try {
+    const { body } = await getJSON("exports/" + current);
+    showStatus(body);
+    if (body.state === "running") {
+      setTimeout(poll, 700);
+    }
+  } catch (err) {
+    showStatus({ state: "failed", error: err.message, rows: 0, pages: 0, total: -1 });
+  }
+}
+
+async function start(event) {
+  event.preventDefault();
+  const form = new URLSearchParams();
+  form.set("uf", $("uf").value);
+  form.set("atividade", $("activity").value);
+  form.set("cidade", $("city").value);
+  form.set("format", new FormData($("export")).get("format"));
+  const columns = [...$("columns").querySelectorAll("input:checked")].map((i) => i.value);
+  if (columns.length === 0) {
+    alert("Escolha pelo menos uma coluna.");
+    return;
+  }
+  form.set("colunas", columns.join(","));
+
+  $("progress").hidden = false;
+  $("bar").removeAttribute("value");
+  showStatus({ state: "running", rows: 0, pages: 0, total: -1 });
+  const resp = await fetch("exports", { method: "POST", body: form });
+  const body = await resp.json();
+  if (!resp.ok) {
+    showStatus({ state: "failed", error: body.error, rows: 0, pages: 0, total: -1 });
+    return;
+  }
+  current = body.id;
+  poll();
+}
+
+async function init() {
+  try {
+    const [u, a, c] = await Promise.all([getJSON("ufs"), getJSON("activities"), getJSON("columns")]);
+    ufs = u.body;
+    noteSources([["UFs", u.source], ["atividades", a.source]]);
+    searchable($("uf-search"), $("uf"), ufs.map((x) => ({ value: x.id, label: `${x.noUf} (${x.sgUf})` })), 24);
+    searchable($("activity-search"), $("activity"),
+      a.body.map((x) => ({ value: x.nuAtividadeTuristica, label: x.noAtividadeTuristica })), 29);
+    renderColumns(c.body);
+  } catch (err) {
+    $("sources").textContent = "Não foi possível carregar as listas: " + err.message;
+    $("sources").classList.add("error");
+    return;
+  }
+  $("uf").addEventListener("change", suggestCities);
+  $("city").addEventListener("input", suggestCities);
+  $("columns-default").addEventListener("click", () => setColumns((n) => defaultColumns.includes(n)));
+  $("columns-all").addEventListener("click", () => setColumns(() => true));
+  $("columns-none").addEventListener("click", () => setColumns(() => false));
+  $("cancel").addEventListener("click", () => current && fetch("exports/" + current, { method: "DELETE" }));
+  $("export").addEventListener("submit", start);
+}
+
+init();
@@ -0,0 +1,100 @@
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes the stats as indented JSON.
+func WriteJSON(w io.Writer, s Stats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// WriteCSV writes the stats as long-format CSV: dimension,value,count,share.
+func WriteCSV(w io.Writer, s Stats) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"dimension", "value", "count", "share"})
+	cw.Write([]string{"total", "", strconv.Itoa(s.Total), "1"})
+	for _, d := range s.dimensions() {
+		for _, c := range d.counts {
+			cw.Write([]string{d.name, c.Value, strconv.Itoa(c.Count), strconv.FormatFloat(c.Share, 'f', 4, 64)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Print writes a compact terminal summary, listing at most top municípios.
+func Print(w io.Writer, s Stats, top int) {
+	if s.Total == 0 {
+		return
+	}
+	var b strings.Builder
+	line := func(title string, counts []Count, limit int) {
+		parts := make([]string, 0, len(counts))
+		for i, c := range counts {
+			if limit > 0 && i == limit {
+				parts = append(parts, fmt.Sprintf("… (+%d)", len(counts)-limit))
+				break
+			}
+			parts = append(parts, fmt.Sprintf("%s %d (%s)", label(c.Value), c.Count, percent(c.Share)))
+		}
+		fmt.Fprintf(&b, "%s: %s\n", title, strings.Join(parts, " | "))
+	}
+
+	line("Municípios", s.ByMunicipio, top)
+	line("Situação", s.BySituacao, 0)
+	line("Tipo de pessoa", s.ByTipoPessoa, 0)
+	line("Possui veículo", s.ByPossuiVeiculo, 0)
+	line("Fim da vigência (ano)", s.ByFimVigenciaAno, 0)
+	line("CEP", s.ByCEPValidacao, 0)
+	line("Documento (CPF/CNPJ)", s.ByDocumentoValidacao, 0)
+	if s.InvalidDocuments > 0 {
+		fmt.Fprintf(&b, "Documentos inválidos (dígito verificador): %d (%s)\n", s.InvalidDocuments, percent(float64(s.InvalidDocuments)/float64(s.Total)))
+	}
+	fmt.Fprintf(&b, "Com website: %d (%s) | Com redes sociais: %d (%s)\n",
+		s.WithWebsite.Count, percent(s.WithWebsite.Share), s.WithRedeSocial.Count, percent(s.WithRedeSocial.Share))
+
+	if len(s.MojibakeRepairs) > 0 {
+		line("Campos com encoding corrigido", s.MojibakeRepairs, 0)
+	}
+
+	io.WriteString(w, b.String())
+}
+
+type dimension struct {
+	name   string
+	counts []Count
+}
+
+func (s Stats) dimensions() []dimension {
+	return []dimension{
+		{"municipio", s.ByMunicipio},
+		{"situacao", s.BySituacao},
+		{"tipoPessoa", s.ByTipoPessoa},
+		{"possuiVeiculo", s.ByPossuiVeiculo},
+		{"fimVigenciaAno", s.ByFimVigenciaAno},
+		{"cepValidacao", s.ByCEPValidacao},
+		{"documentoValidacao", s.ByDocumentoValidacao},
+		{"website", []Count{s.WithWebsite}},
+		{"redeSocial", []Count{s.WithRedeSocial}},
+		{"mojibakeRepairs", s.MojibakeRepairs},
+	}
+}
+
+func label(v string) string {
+	if v == "" {
+		return "(vazio)"
+	}
+	return v
+}
+
+func percent(share float64) string {
+	return strconv.FormatFloat(share*100, 'f', 1, 64) + "%"
+}
@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+
+	"cadastur-csv/internal/cadastur"
+	"cadastur-csv/internal/normalize"
+)
+
+// Count is one value of a breakdown with how many providers have it.
+type Count struct {
+	Value string  `json:"value"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// Stats is an aggregated view of an export.
+type Stats struct {
+	Total            int     `json:"total"`
+	ByMunicipio      []Count `json:"byMunicipio"`
+	BySituacao       []Count `json:"bySituacao"`
+	ByTipoPessoa     []Count `json:"byTipoPessoa"`
+	ByPossuiVeiculo  []Count `json:"byPossuiVeiculo"`
+	ByFimVigenciaAno []Count `json:"byFimVigenciaAno"`
+	ByCEPValidacao   []Count `json:"byCepValidacao"`
+	// ByDocumentoValidacao counts CPF/CNPJ check results; partners reject invalid documents.
+	ByDocumentoValidacao []Count `json:"byDocumentoValidacao"`
+	InvalidDocuments     int     `json:"invalidDocuments"`
+	WithWebsite          Count   `json:"withWebsite"`
+	WithRedeSocial       Count   `json:"withRedeSocial"`
+	// MojibakeRepairs counts, per column, the fields whose encoding was repaired.
+	MojibakeRepairs []Count `json:"mojibakeRepairs"`
+}
+
+// Collector accumulates Stats while rows are being exported.
+type Collector struct {
+	total      int
+	municipio  map[string]int
+	situacao   map[string]int
+	tipoPessoa map[string]int
+	veiculo    map[string]int
+	fimAno     map[string]int
+	cep        map[string]int
+	documento  map[string]int
+	repairs    map[string]int
+	website    int
+	redeSocial int
+}
+
+// NewCollector creates an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		municipio:  make(map[string]int),
+		situacao:   make(map[string]int),
+		tipoPessoa: make(map[string]int),
+		veiculo:    make(map[string]int),
+		fimAno:     make(map[string]int),
+		cep:        make(map[string]int),
+		documento:  make(map[string]int),
+		repairs:    make(map[string]int),
+	}
+}
+
+// Add counts one provider.
+func (c *Collector) Add(p cadastur.Prestador) {
+	c.total++
+	c.municipio[normalize.FixMojibake(string(p.Municipio))]++
+	c.situacao[normalize.FixMojibake(string(p.Situacao))]++
+	c.tipoPessoa[string(p.TipoPessoa)]++
+	c.veiculo[normalize.BoolToStr(bool(p.FlPossuiVeiculo))]++
+
+	year := ""
+	if d := normalize.MsToDate(int64(p.DtFimVigencia)); len(d) >= 4 {
+		year = d[:4]
+	}
+	c.fimAno[year]++
+	c.cep[normalize.CheckCEP(string(p.NuCep), string(p.Sguf))]++
+	c.documento[normalize.ProviderDocument(string(p.RegistroRf), string(p.TipoPessoa)).Status]++
+
+	if strings.TrimSpace(string(p.NoWebSite)) != "" {
+		c.website++
+	}
+	if strings.TrimSpace(string(p.AtividadeRedeSociais)) != "" {
+		c.redeSocial++
+	}
+}
+
+// AddRepair counts one field of column whose mojibake was repaired.
+func (c *Collector) AddRepair(column string) {
+	c.repairs[column]++
+}
+
+// Stats returns the current aggregates. Breakdowns are sorted by count
+// (descending), except vigência end years, which are sorted by year.
+func (c *Collector) Stats() Stats {
+	years := c.counts(c.fimAno)
+	sort.Slice(years, func(i, j int) bool { return years[i].Value < years[j].Value })
+
+	return Stats{
+		Total:                c.total,
+		ByMunicipio:          c.counts(c.municipio),
+		BySituacao:           c.counts(c.situacao),
+		ByTipoPessoa:         c.counts(c.tipoPessoa),
+		ByPossuiVeiculo:      c.counts(c.veiculo),
+		ByFimVigenciaAno:     years,
+		ByCEPValidacao:       c.counts(c.cep),
+		ByDocumentoValidacao: c.counts(c.documento),
+		InvalidDocuments:     c.documento[normalize.DocumentInvalid],
+		WithWebsite:          c.count("true", c.website),
+		WithRedeSocial:       c.count("true", c.redeSocial),
+		MojibakeRepairs:      c.counts(c.repairs),
+	}
+}
+
+func (c *Collector) counts(m map[string]int) []Count {
+	out := make([]Count, 0, len(m))
+	for v, n := range m {
+		out = append(out, c.count(v, n))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
+
+func (c *Collector) count(value string, n int) Count {
+	share := 0.0
+	if c.total > 0 {
+		share = float64(n) / float64(c.total)
+	}
+	return Count{Value: value, Count: n, Share: share}
+}
@@ -0,0 +1,287 @@
+// Package webhook sends signed JSON notifications about export runs to the
+// URLs configured in a manifest.
+//
+// Every delivery is a POST with the JSON Payload as body and these headers:
+//
+//	X-Cadastur-Events:    success,changes
+//	X-Cadastur-Delivery:  random id, the same on every retry
+//	X-Cadastur-Timestamp: Unix seconds of the attempt
+//	X-Cadastur-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// The signature is only sent when the hook has a secret. Receivers should
+// recompute it and reject old timestamps.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cadastur-csv/internal/diff"
+)
+
+// Events a hook can subscribe to.
+const (
+	// EventSuccess is sent when a run finishes.
+	EventSuccess = "success"
+	// EventFailure is sent when a run fails.
+	EventFailure = "failure"
+	// EventChanges is sent when a run finds new or removed providers
+	// compared with the previous successful run of the job.
+	EventChanges = "changes"
+)
+
+// Events lists every event, in the order they appear in payloads.
+var Events = []string{EventSuccess, EventFailure, EventChanges}
+
+// maxListed bounds the providers listed in a payload's diff; the counts
+// are always complete.
+const maxListed = 50
+
+// Run is the outcome of one export, as reported by the batch and daemon
+// commands.
+type Run struct {
+	Job          string    `json:"job"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	StartedAt    time.Time `json:"startedAt"`
+	DurationMs   int64     `json:"durationMs"`
+	Output       string    `json:"output,omitempty"`
+	Rows         int       `json:"rows"`
+	Pages        int       `json:"pages"`
+	TotalResults int       `json:"totalResults"`
+}
+
+// Provider identifies a provider in a diff.
+type Provider struct {
+	Key       string `json:"key"`
+	Name      string `json:"nomePrestador"`
+	Municipio string `json:"municipio,omitempty"`
+}
+
+// Changes summarizes the comparison of a run with the previous one. Added
+// and Removed list at most 50 providers each; Truncated tells when there
+// were more.
+type Changes struct {
+	Previous  string       `json:"previous"`
+	Key       string       `json:"key"`
+	Summary   diff.Summary `json:"summary"`
+	Added     []Provider   `json:"added"`
+	Removed   []Provider   `json:"removed"`
+	Truncated bool         `json:"truncated"`
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Events   []string  `json:"events"`
+	Source   string    `json:"source"`
+	Manifest string    `json:"manifest,omitempty"`
+	Run      Run       `json:"run"`
+	Changes  *Changes  `json:"changes,omitempty"`
+	SentAt   time.Time `json:"sentAt"`
+}
+
+// NewChanges builds the diff summary of a payload from a comparison of the
+// previous export (at path previous) with the new one.
+func NewChanges(previous string, res diff.Result) *Changes {
+	c := &Changes{Previous: previous, Key: res.Key, Summary: res.Summary}
+	c.Added, c.Truncated = providers(res.Key, res.Added)
+	var more bool
+	c.Removed, more = providers(res.Key, res.Removed)
+	c.Truncated = c.Truncated || more
+	return c
+}
+
+func providers(key string, rows []map[string]string) ([]Provider, bool) {
+	list := make([]Provider, 0, min(len(rows), maxListed))
+	for _, row := range rows[:min(len(rows), maxListed)] {
+		list = append(list, Provider{Key: row[key], Name: row["nomePrestador"], Municipio: row["municipio"]})
+	}
+	return list, len(rows) > maxListed
+}
+
+// Hook is a configured webhook.
+type Hook struct {
+	Name string
+	URL  string
+	// Events are the subscribed events.
+	Events []string
+	// Jobs restricts the hook to some jobs; empty means every job.
+	Jobs []string
+	// Secret signs the deliveries; empty sends them unsigned.
+	Secret string
+	// Retries is how many times a delivery is repeated after a network
+	// error, 429 or 5xx response.
+	Retries int
+	Timeout time.Duration
+}
+
+// ParseHook reads a [webhooks.<name>] table:
+//
+//	url = "https://example.com/cadastur"        # required
+//	events = ["failure", "changes"]             # default: every event
+//	jobs = ["sc-guias"]                         # default: every job
+//	secret-env = "CADASTUR_WEBHOOK_SECRET"      # or secret = "..."
+//	retries = 3
+//	timeout = "10s"
+func ParseHook(name string, settings map[string]string) (*Hook, error) {
+	h := &Hook{Name: name, Events: Events, Retries: 3, Timeout: 10 * time.Second}
+	for key, v := range settings {
+		switch key {
+		case "url":
+			u, err := url.Parse(v)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, fmt.Errorf("invalid url %q: want http:// or https://", v)
+			}
+			h.URL = v
+		case "events":
+			h.Events = splitList(v)
+			for _, e := range h.Events {
+				if !slices.Contains(Events, e) {
+					return nil, fmt.Errorf("unknown event %q (use %s)", e, strings.Join(Events, ", "))
+				}
+			}
+		case "jobs":
+			h.Jobs = splitList(v)
+		case "secret":
+			h.Secret = v
+		case "secret-env":
+			if h.Secret = os.Getenv(v); h.Secret == "" {
+				return nil, fmt.Errorf("secret-env: variable %s is not set", v)
+			}
+		case "retries":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid retries %q", v)
+			}
+			h.Retries = n
+		case "timeout":
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid timeout %q", v)
+			}
+			h.Timeout = d
+		default:
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	if h.URL == "" {
+		return nil, fmt.Errorf("missing url")
+	}
+	if len(h.Events) == 0 {
+		return nil, fmt.Errorf("events must not be empty")
+	}
+	return h, nil
+}
+
+// Wants reports whether the hook subscribes to any of events for job.
+func (h *Hook) Wants(job string, events []string) bool {
+	if len(h.Jobs) > 0 && !slices.Contains(h.Jobs, job) {
+		return false
+	}
+	for _, e := range events {
+		if slices.Contains(h.Events, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign returns the X-Cadastur-Signature value of body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send delivers p to the hook, retrying network errors, 429 and 5xx
+// responses with a backoff that starts at one second and doubles.
+func (h *Hook) Send(ctx context.Context, p Payload) error {
+	if p.SentAt.IsZero() {
+		p.SentAt = time.Now()
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	delivery := hex.EncodeToString(b)
+	client := &http.Client{Timeout: h.Timeout}
+
+	wait := time.Second
+	for attempt := 0; ; attempt++ {
+		status, err := h.post(ctx, client, delivery, p.Events, body)
+		if err == nil {
+			slog.InfoContext(ctx, "webhook delivered", "hook", h.Name, "events", p.Events, "job", p.Run.Job, "status", status, "attempts", attempt+1)
+			return nil
+		}
+		retryable := status == 0 || status == http.StatusTooManyRequests || status >= 500
+		if !retryable || attempt >= h.Retries || ctx.Err() != nil {
+			slog.ErrorContext(ctx, "webhook failed", "hook", h.Name, "events", p.Events, "job", p.Run.Job, "status", status, "attempts", attempt+1, "err", err)
+			return fmt.Errorf("webhook %s: %w", h.Name, err)
+		}
+		slog.WarnContext(ctx, "retrying webhook", "hook", h.Name, "status", status, "retry", attempt+1, "wait", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("webhook %s: %w", h.Name, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post performs one attempt. status is 0 when no response was received.
+func (h *Hook) post(ctx context.Context, client *http.Client, delivery string, events []string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cadastur-csv")
+	req.Header.Set("X-Cadastur-Events", strings.Join(events, ","))
+	req.Header.Set("X-Cadastur-Delivery", delivery)
+	req.Header.Set("X-Cadastur-Timestamp", timestamp)
+	if h.Secret != "" {
+		req.Header.Set("X-Cadastur-Signature", Sign(h.Secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("POST %s: unexpected HTTP status %s", h.URL, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// splitList splits a comma-separated setting, dropping empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
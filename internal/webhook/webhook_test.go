@@ -0,0 +1,223 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the deliveries of a test server answering with status.
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) server(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		rc.requests = append(rc.requests, r)
+		rc.bodies = append(rc.bodies, body)
+		rc.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func testPayload() Payload {
+	return Payload{
+		Events: []string{EventSuccess, EventChanges},
+		Source: "daemon",
+		Run:    Run{Job: "sc-guias", Status: "ok", Rows: 10},
+	}
+}
+
+func TestSendSignsDelivery(t *testing.T) {
+	var rc receiver
+	srv := rc.server(t, http.StatusNoContent)
+	h := &Hook{Name: "equipe", URL: srv.URL, Secret: "segredo", Timeout: time.Second}
+
+	if err := h.Send(context.Background(), testPayload()); err != nil {
+		t.Fatal(err)
+	}
+	if rc.count() != 1 {
+		t.Fatalf("got %d requests, want 1", rc.count())
+	}
+	r, body := rc.requests[0], rc.bodies[0]
+	ts := r.Header.Get("X-Cadastur-Timestamp")
+	if ts == "" {
+		t.Fatal("missing X-Cadastur-Timestamp")
+	}
+	if got, want := r.Header.Get("X-Cadastur-Signature"), Sign("segredo", ts, body); got != want {
+		t.Errorf("X-Cadastur-Signature = %q, want %q", got, want)
+	}
+	if got := r.Header.Get("X-Cadastur-Events"); got != "success,changes" {
+		t.Errorf("X-Cadastur-Events = %q, want %q", got, "success,changes")
+	}
+	if r.Header.Get("X-Cadastur-Delivery") == "" {
+		t.Error("missing X-Cadastur-Delivery")
+	}
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Run.Job != "sc-guias" || p.SentAt.IsZero() {
+		t.Errorf("payload = %+v", p)
+	}
+}
+
+func TestSendUnsigned(t *testing.T) {
+	var rc receiver
+	srv := rc.server(t, http.StatusOK)
+	h := &Hook{Name: "equipe", URL: srv.URL, Timeout: time.Second}
+	if err := h.Send(context.Background(), testPayload()); err != nil {
+		t.Fatal(err)
+	}
+	if sig := rc.requests[0].Header.Get("X-Cadastur-Signature"); sig != "" {
+		t.Errorf("unsigned hook sent X-Cadastur-Signature %q", sig)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// printf '1700000000.{}' | openssl dgst -sha256 -hmac segredo
+	want := "sha256=28d73844c84580182772a1e98a60aeb8f04184b1bef0d4e147cfa59ebf0bfedc"
+	if got := Sign("segredo", "1700000000", []byte("{}")); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		retries  int
+		attempts int
+	}{
+		{"5xx retried", http.StatusServiceUnavailable, 2, 3},
+		{"429 retried", http.StatusTooManyRequests, 1, 2},
+		{"4xx not retried", http.StatusBadRequest, 2, 1},
+		{"no retries", http.StatusInternalServerError, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rc receiver
+			srv := rc.server(t, tt.status)
+			h := &Hook{Name: "equipe", URL: srv.URL, Retries: tt.retries, Timeout: time.Second}
+			if err := h.Send(context.Background(), testPayload()); err == nil {
+				t.Fatal("Send succeeded, want an error")
+			}
+			if rc.count() != tt.attempts {
+				t.Errorf("got %d attempts, want %d", rc.count(), tt.attempts)
+			}
+			delivery := rc.requests[0].Header.Get("X-Cadastur-Delivery")
+			for _, r := range rc.requests[1:] {
+				if r.Header.Get("X-Cadastur-Delivery") != delivery {
+					t.Error("X-Cadastur-Delivery changed between retries")
+				}
+			}
+		})
+	}
+}
+
+func TestSendStopsOnCancel(t *testing.T) {
+	var rc receiver
+	srv := rc.server(t, http.StatusBadGateway)
+	h := &Hook{Name: "equipe", URL: srv.URL, Retries: 5, Timeout: time.Second}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := h.Send(ctx, testPayload()); err == nil {
+		t.Fatal("Send succeeded, want an error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Send took %v after the context ended", d)
+	}
+	if rc.count() != 1 {
+		t.Errorf("got %d attempts, want 1", rc.count())
+	}
+}
+
+func TestParseHook(t *testing.T) {
+	h, err := ParseHook("equipe", map[string]string{
+		"url":     "https://example.com/cadastur",
+		"events":  "failure, changes",
+		"jobs":    "sc-guias,rs-agencias",
+		"retries": "0",
+		"timeout": "5s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(h.Events, ",") != "failure,changes" || strings.Join(h.Jobs, ",") != "sc-guias,rs-agencias" || h.Retries != 0 || h.Timeout != 5*time.Second {
+		t.Errorf("ParseHook = %+v", h)
+	}
+
+	h, err = ParseHook("padrao", map[string]string{"url": "http://localhost:9000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(h.Events, ",") != "success,failure,changes" || h.Jobs != nil || h.Retries != 3 || h.Timeout != 10*time.Second {
+		t.Errorf("ParseHook defaults = %+v", h)
+	}
+}
+
+func TestParseHookErrors(t *testing.T) {
+	tests := []struct {
+		settings map[string]string
+		want     string
+	}{
+		{map[string]string{}, "missing url"},
+		{map[string]string{"url": "ftp://example.com"}, "invalid url"},
+		{map[string]string{"url": "example.com"}, "invalid url"},
+		{map[string]string{"url": "https://example.com", "events": "success,deleted"}, `unknown event "deleted"`},
+		{map[string]string{"url": "https://example.com", "events": " , "}, "events must not be empty"},
+		{map[string]string{"url": "https://example.com", "retries": "-1"}, "invalid retries"},
+		{map[string]string{"url": "https://example.com", "retries": "três"}, "invalid retries"},
+		{map[string]string{"url": "https://example.com", "timeout": "0s"}, "invalid timeout"},
+		{map[string]string{"url": "https://example.com", "secret-env": "CADASTUR_TEST_UNSET_SECRET"}, "is not set"},
+		{map[string]string{"url": "https://example.com", "method": "PUT"}, `unknown setting "method"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseHook("equipe", tt.settings)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseHook(%v) error = %v, want %q", tt.settings, err, tt.want)
+		}
+	}
+}
+
+func TestWants(t *testing.T) {
+	all := &Hook{Events: Events}
+	failures := &Hook{Events: []string{EventFailure}, Jobs: []string{"sc-guias"}}
+	tests := []struct {
+		hook   *Hook
+		job    string
+		events []string
+		want   bool
+	}{
+		{all, "qualquer", []string{EventSuccess}, true},
+		{all, "qualquer", []string{EventSuccess, EventChanges}, true},
+		{failures, "sc-guias", []string{EventFailure}, true},
+		{failures, "sc-guias", []string{EventSuccess, EventChanges}, false},
+		{failures, "rs-agencias", []string{EventFailure}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.hook.Wants(tt.job, tt.events); got != tt.want {
+			t.Errorf("Hook{Events: %v, Jobs: %v}.Wants(%q, %v) = %v, want %v", tt.hook.Events, tt.hook.Jobs, tt.job, tt.events, got, tt.want)
+		}
+	}
+}